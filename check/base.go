@@ -20,6 +20,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/dependency"
@@ -106,6 +107,25 @@ func (b *Base) getState() bool {
 	return b.WasSuccessful
 }
 
+// maxMessageSize bounds the size of check messages, which often
+// include the output of commands.
+const maxMessageSize = 64 * 1024
+
+// truncateMessage shortens messages longer than maxMessageSize,
+// marking how much was removed.
+func truncateMessage(msg string) string {
+	if len(msg) <= maxMessageSize {
+		return msg
+	}
+
+	cut := maxMessageSize
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s\n[... truncated %d bytes]", msg[:cut], len(msg)-cut)
+}
+
 func (b *Base) setMessage(m interface{}) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
@@ -122,6 +142,8 @@ func (b *Base) setMessage(m interface{}) {
 	default:
 		b.Message = fmt.Sprintf("%+v", msg)
 	}
+
+	b.Message = truncateMessage(b.Message)
 }
 
 // Suites reports which suites the current check belongs to.
@@ -1,9 +1,15 @@
 package check
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/registry"
@@ -32,62 +38,499 @@ func init() {
 	}
 }
 
+// outputAssertions describes literal (Contains/NotContains) and
+// regular expression (Matches/NotMatches) assertions about the output
+// of a command.
+type outputAssertions struct {
+	Contains    []string `bson:"contains" json:"contains" yaml:"contains"`
+	NotContains []string `bson:"not_contains" json:"not_contains" yaml:"not_contains"`
+	Matches     []string `bson:"matches" json:"matches" yaml:"matches"`
+	NotMatches  []string `bson:"not_matches" json:"not_matches" yaml:"not_matches"`
+}
+
+func (a *outputAssertions) validate() error {
+	catcher := grip.NewCatcher()
+
+	_, err := compilePatterns(a.Matches)
+	catcher.Add(err)
+	_, err = compilePatterns(a.NotMatches)
+	catcher.Add(err)
+
+	return catcher.Resolve()
+}
+
+// check returns a list of messages that describe every assertion
+// that the output of the named stream does not satisfy. Trailing
+// newlines are removed from the output first.
+func (a *outputAssertions) check(stream, output string) []string {
+	var problems []string
+	output = strings.TrimRight(output, "\r\n")
+
+	for _, literal := range a.Contains {
+		if !strings.Contains(output, literal) {
+			problems = append(problems, fmt.Sprintf("%s does not contain '%s'", stream, literal))
+		}
+	}
+
+	for _, literal := range a.NotContains {
+		if strings.Contains(output, literal) {
+			problems = append(problems, fmt.Sprintf("%s contains '%s'", stream, literal))
+		}
+	}
+
+	matches, _ := compilePatterns(a.Matches)
+	for _, re := range matches {
+		if !re.MatchString(output) {
+			problems = append(problems, fmt.Sprintf("%s does not match '%s'", stream, re))
+		}
+	}
+
+	notMatches, _ := compilePatterns(a.NotMatches)
+	for _, re := range notMatches {
+		if re.MatchString(output) {
+			problems = append(problems, fmt.Sprintf("%s matches '%s'", stream, re))
+		}
+	}
+
+	return problems
+}
+
+// maxCapturedOutput is the default number of bytes of each output
+// stream of a command that checks keep.
+const maxCapturedOutput = 1024 * 1024
+
+// cappedBuffer is a buffer that is safe to write to from the
+// goroutines that copy a command's stdout and stderr. It keeps the
+// first limit bytes of output, and counts the bytes that it discards,
+// without failing the writes.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated int64
+	mutex     sync.Mutex
+}
+
+func newCappedBuffer() *cappedBuffer {
+	return &cappedBuffer{limit: maxCapturedOutput}
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	keep := b.limit - b.buf.Len()
+	if keep > len(p) {
+		keep = len(p)
+	} else if keep < 0 {
+		keep = 0
+	}
+
+	b.truncated += int64(len(p) - keep)
+	b.buf.Write(p[:keep])
+
+	return len(p), nil
+}
+
+// contents returns the output that the buffer kept.
+func (b *cappedBuffer) contents() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.buf.String()
+}
+
+// discarded returns the number of bytes that the buffer discarded.
+func (b *cappedBuffer) discarded() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.truncated
+}
+
+// String returns the output that the buffer kept, and notes how much
+// output it discarded.
+func (b *cappedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.truncated == 0 {
+		return b.buf.String()
+	}
+
+	return fmt.Sprintf("%s\n[... truncated %d bytes]", b.buf.String(), b.truncated)
+}
+
+// joinOutput returns the output of a command for check messages,
+// starting with notes about discarded output, which would otherwise be
+// lost when the message is truncated.
+func joinOutput(stdout, stderr *cappedBuffer) string {
+	var out []string
+	for stream, buf := range map[string]*cappedBuffer{"stdout": stdout, "stderr": stderr} {
+		if discarded := buf.discarded(); discarded > 0 {
+			out = append(out, fmt.Sprintf("[... discarded %d bytes of %s]", discarded, stream))
+		}
+	}
+	sort.Strings(out)
+
+	for _, buf := range []*cappedBuffer{stdout, stderr} {
+		if output := strings.TrimRight(buf.contents(), "\r\n"); output != "" {
+			out = append(out, output)
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// shellOperation runs a command, specified in one of three forms:
+// Command is run with "sh -c", Argv is a list of arguments that is run
+// directly without a shell, and Exec is split into arguments using
+// shell quoting rules (see splitCommandLine) and also run without a
+// shell. Only one form may be specified. By default the
+// shell-operation check passes if the command exits zero, and
+// shell-operation-error passes if the command exits non-zero:
+// ExitCodes replaces this default with a list of acceptable exit
+// codes. Timeout is a duration (e.g. "30s"): commands that run longer
+// are killed, along with all of their children, and the check
+// fails. Limits bound the resources that the command may use (see
+// processLimits). Stdout and Stderr hold assertions about each output
+// stream, which apply to the first 1MiB of the stream: greenbay
+// discards the rest, and notes how much in the check's message.
+//
+// EnvironmentMode determines how Environment relates to the
+// environment of the greenbay process: "replace" (the default) runs
+// the command with only the variables in Environment, "merge" adds
+// Environment to the process environment, and "allowlist" adds
+// Environment to the process variables named in
+// EnvironmentAllowlist. Values may refer to other variables, e.g.
+// "/opt/foo/bin:$PATH": see expandEnvironment.
+//
+// RunAs runs the command as another user, which requires running
+// greenbay as root. Unless the mode is "replace", the command's HOME,
+// USER, and LOGNAME describe that user.
 type shellOperation struct {
-	Command          string            `bson:"command" json:"command" yaml:"command"`
-	WorkingDirectory string            `bson:"working_directory" json:"working_directory" yaml:"working_directory"`
-	Environment      map[string]string `bson:"environment" json:"environment" yaml:"environment"`
-	*Base            `bson:"metadata" json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Command              string            `bson:"command" json:"command" yaml:"command"`
+	Argv                 []string          `bson:"argv" json:"argv" yaml:"argv"`
+	Exec                 string            `bson:"exec" json:"exec" yaml:"exec"`
+	WorkingDirectory     string            `bson:"working_directory" json:"working_directory" yaml:"working_directory"`
+	Environment          map[string]string `bson:"environment" json:"environment" yaml:"environment"`
+	EnvironmentMode      string            `bson:"environment_mode" json:"environment_mode" yaml:"environment_mode"`
+	EnvironmentAllowlist []string          `bson:"environment_allowlist" json:"environment_allowlist" yaml:"environment_allowlist"`
+	Timeout              string            `bson:"timeout" json:"timeout" yaml:"timeout"`
+	ExitCodes            []int             `bson:"exit_codes" json:"exit_codes" yaml:"exit_codes"`
+	Stdout               outputAssertions  `bson:"stdout" json:"stdout" yaml:"stdout"`
+	Stderr               outputAssertions  `bson:"stderr" json:"stderr" yaml:"stderr"`
+	RunAs                *runAsSpec        `bson:"run_as" json:"run_as" yaml:"run_as"`
+	Limits               *processLimits    `bson:"limits" json:"limits" yaml:"limits"`
+	*Base                `bson:"metadata" json:"metadata,omitempty" yaml:"metadata,omitempty"`
 
 	shouldFail bool
 }
 
+func (c *shellOperation) validate() (*commandLimits, error) {
+	catcher := grip.NewCatcher()
+
+	forms := 0
+	for _, set := range []bool{c.Command != "", len(c.Argv) > 0, c.Exec != ""} {
+		if set {
+			forms++
+		}
+	}
+
+	if forms != 1 {
+		catcher.Add(errors.Errorf("'%s' (%s) check must specify exactly one of command, argv, or exec",
+			c.ID(), c.Name()))
+	} else if len(c.Argv) > 0 && c.Argv[0] == "" {
+		catcher.Add(errors.Errorf("argv for '%s' (%s) check does not specify a program",
+			c.ID(), c.Name()))
+	} else if _, err := c.args(); err != nil {
+		catcher.Add(err)
+	}
+
+	switch c.EnvironmentMode {
+	case "", "replace", "merge":
+		if len(c.EnvironmentAllowlist) > 0 {
+			catcher.Add(errors.Errorf("environment allowlist for '%s' (%s) check requires the allowlist mode",
+				c.ID(), c.Name()))
+		}
+	case "allowlist":
+	default:
+		catcher.Add(errors.Errorf("environment mode '%s' for '%s' (%s) check is not valid",
+			c.EnvironmentMode, c.ID(), c.Name()))
+	}
+
+	catcher.Add(c.Stdout.validate())
+	catcher.Add(c.Stderr.validate())
+
+	var timeout time.Duration
+	if c.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "invalid timeout for '%s' (%s) check", c.ID(), c.Name()))
+		} else if timeout <= 0 {
+			catcher.Add(errors.Errorf("timeout for '%s' (%s) check must be positive", c.ID(), c.Name()))
+		}
+	}
+
+	limits, err := c.Limits.resolve(timeout)
+	catcher.Add(errors.Wrapf(err, "invalid limits for '%s' (%s) check", c.ID(), c.Name()))
+
+	return limits, catcher.Resolve()
+}
+
+// expandEnvironment expands references to variables in the values of
+// env. References to other variables in env use their (expanded)
+// values, and all other references, including references to the
+// variable itself (e.g. "PATH: /opt/foo/bin:$PATH"), use the value from
+// the process environment. References to variables in env are only
+// expanded one level deep.
+func expandEnvironment(env map[string]string) map[string]string {
+	out := make(map[string]string, len(env))
+
+	for key, value := range env {
+		key := key
+		out[key] = os.Expand(value, func(name string) string {
+			if other, ok := env[name]; ok && name != key {
+				return os.ExpandEnv(other)
+			}
+
+			return os.Getenv(name)
+		})
+	}
+
+	return out
+}
+
+// environment returns the environment for the command, in the form
+// used by exec.Cmd, or nil if the command should inherit the process
+// environment unmodified.
+func (c *shellOperation) environment(identity *processIdentity) []string {
+	if len(c.Environment) == 0 && c.EnvironmentMode != "allowlist" {
+		if identity != nil {
+			return identity.environment(nil)
+		}
+		return nil
+	}
+
+	vars := map[string]string{}
+
+	switch c.EnvironmentMode {
+	case "merge":
+		for _, pair := range identity.environment(os.Environ()) {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) == 2 {
+				vars[parts[0]] = parts[1]
+			}
+		}
+	case "allowlist":
+		for _, key := range c.EnvironmentAllowlist {
+			if value, ok := os.LookupEnv(key); ok {
+				vars[key] = value
+			}
+		}
+
+		for _, pair := range identity.environment([]string{}) {
+			parts := strings.SplitN(pair, "=", 2)
+			vars[parts[0]] = parts[1]
+		}
+	}
+
+	for key, value := range expandEnvironment(c.Environment) {
+		vars[key] = value
+	}
+
+	env := make([]string, 0, len(vars))
+	for key, value := range vars {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(env)
+
+	return env
+}
+
+// args returns the program and arguments to run.
+func (c *shellOperation) args() ([]string, error) {
+	switch {
+	case len(c.Argv) > 0:
+		return c.Argv, nil
+	case c.Exec != "":
+		args, err := splitCommandLine(c.Exec)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) == 0 {
+			return nil, errors.Errorf("exec '%s' does not specify a program", c.Exec)
+		}
+
+		return args, nil
+	default:
+		return []string{"sh", "-c", c.Command}, nil
+	}
+}
+
+// commandString returns a description of the command for messages.
+func (c *shellOperation) commandString() string {
+	switch {
+	case len(c.Argv) > 0:
+		return strings.Join(c.Argv, " ")
+	case c.Exec != "":
+		return c.Exec
+	default:
+		return c.Command
+	}
+}
+
+// acceptableExitCode reports if an exit code satisfies the check.
+func (c *shellOperation) acceptableExitCode(code int) bool {
+	if len(c.ExitCodes) == 0 {
+		return (code == 0) != c.shouldFail
+	}
+
+	for _, expected := range c.ExitCodes {
+		if code == expected {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exitCode returns the exit code of a command from the error returned
+// by Wait, or -1 if the command did not exit normally.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Exited() {
+			return status.ExitStatus()
+		}
+	}
+
+	return -1
+}
+
 func (c *shellOperation) Run() {
 	c.startTask()
 	defer c.MarkComplete()
 
-	logMsg := []string{fmt.Sprintf("command='%s'", c.Command)}
+	limits, err := c.validate()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	identity, err := c.RunAs.resolve()
+	if err != nil {
+		c.setState(false)
+		c.setMessage(err.Error())
+		c.AddError(errors.Wrapf(err, "problem running '%s' (%s) check as another user", c.ID(), c.Name()))
+		return
+	}
 
-	// I don't like "sh -c" as a thing, but it parallels the way
-	// that Evergreen runs tasks (for now,) and it gets us away
-	// from needing to do special shlex parsing, though
-	// (https://github.com/google/shlex) seems like a good start.
-	cmd := exec.Command("sh", "-c", c.Command)
+	// "sh -c" parallels the way that Evergreen runs tasks, but
+	// argv and exec forms run commands directly, which works on
+	// systems without a shell and avoids quoting problems.
+	args, _ := c.args()
+	name := c.commandString()
+	logMsg := []string{fmt.Sprintf("command='%s'", name)}
+
+	cmd := exec.Command(args[0], args[1:]...)
 	if c.WorkingDirectory != "" {
 		cmd.Dir = c.WorkingDirectory
 		logMsg = append(logMsg, fmt.Sprintf("dir='%s'", c.WorkingDirectory))
 	}
 
-	if len(c.Environment) > 0 {
-		env := []string{}
+	if env := c.environment(identity); env != nil {
+		cmd.Env = env
+
+		configured := make([]string, 0, len(c.Environment))
 		for key, value := range c.Environment {
-			env = append(env, fmt.Sprintf("%s=%s", key, value))
+			configured = append(configured, fmt.Sprintf("%s=%s", key, value))
 		}
-		cmd.Env = env
-		logMsg = append(logMsg, fmt.Sprintf("env='%s'", strings.Join(env, " ")))
+		logMsg = append(logMsg, fmt.Sprintf("env='%s'", strings.Join(configured, " ")))
+		if c.EnvironmentMode != "" {
+			logMsg = append(logMsg, fmt.Sprintf("env_mode='%s'", c.EnvironmentMode))
+		}
+	}
+
+	stdout := newCappedBuffer()
+	stderr := newCappedBuffer()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if identity != nil {
+		identity.apply(cmd)
+		logMsg = append(logMsg, fmt.Sprintf("run_as='%s'", identity))
 	}
 
-	out, err := cmd.CombinedOutput()
+	err = runCommand(cmd, limits)
+	limitErr, exceeded := err.(*limitError)
+	setupErr, notStarted := err.(*limitSetupError)
+
+	code := exitCode(err)
+	logMsg = append(logMsg, fmt.Sprintf("exit=%d", code))
 	if err != nil {
 		logMsg = append(logMsg, fmt.Sprintf("err='%+v'", err))
+	}
+
+	var problems []string
+	if identity != nil && isCredentialError(err) {
+		problems = append(problems, fmt.Sprintf("greenbay lacks the privilege to run commands as %s", identity))
+	}
+
+	switch {
+	case notStarted:
+		c.setState(false)
+		c.AddError(errors.Errorf("command '%s' did not run: %s", name, setupErr))
+		problems = append(problems, fmt.Sprintf("greenbay could not set the command's limits: %s", setupErr))
+	case exceeded:
+		c.setState(false)
+		c.AddError(errors.Errorf("command '%s' %s", name, limitErr))
+		problems = append(problems, fmt.Sprintf("command %s and was killed", limitErr))
+	case !c.acceptableExitCode(code):
+		c.setState(false)
+		if len(c.ExitCodes) > 0 {
+			c.AddError(errors.Errorf("command '%s' exited %d, expected one of %v",
+				name, code, c.ExitCodes))
+		} else if c.shouldFail {
+			c.AddError(errors.Errorf("command '%s' succeeded but test expects it to fail",
+				name))
+		} else {
+			c.AddError(errors.Wrapf(err, "command '%s' failed", name))
+		}
+	default:
+		problems = append(problems, c.Stdout.check("stdout", stdout.contents())...)
+		problems = append(problems, c.Stderr.check("stderr", stderr.contents())...)
 
-		if !c.shouldFail {
+		if len(problems) > 0 {
 			c.setState(false)
-			c.AddError(errors.Wrapf(err, "command failed",
-				c.ID(), c.Command))
+			c.AddError(errors.Errorf("output of command '%s' does not satisfy %d assertion(s)",
+				name, len(problems)))
 		} else {
 			c.setState(true)
 		}
-	} else if c.shouldFail {
-		c.setState(false)
-		c.AddError(errors.Errorf("command '%s' succeeded but test expects it to fail",
-			c.Command))
-	} else {
-		c.setState(true)
 	}
 
 	grip.Debug(strings.Join(logMsg, ", "))
 
+	if identity != nil {
+		ranAs := identity.describeOutput("")
+		if c.getState() {
+			c.setMessage(ranAs)
+			return
+		}
+		problems = append([]string{ranAs}, problems...)
+	}
+
 	if !c.getState() {
-		c.setMessage(string(out))
+		if len(problems) == 0 {
+			c.setMessage(joinOutput(stdout, stderr))
+		} else {
+			c.setMessage(append(problems, joinOutput(stdout, stderr)))
+		}
 	}
 }
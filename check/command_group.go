@@ -2,12 +2,10 @@ package check
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/registry"
 	"github.com/mongodb/greenbay"
-	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
@@ -28,10 +26,54 @@ func registerCommandGroupChecks() {
 	}
 }
 
+// shellGroup runs a group of shell operations. Environment,
+// EnvironmentMode, and EnvironmentAllowlist are defaults for all
+// commands in the group, and have the same semantics as they do for
+// individual shell operations. Commands may override the mode and the
+// allowlist, and only commands in the allowlist mode inherit the
+// group's allowlist. Variables in the command's environment take
+// precedence over the group's. RunAs and Limits are defaults for
+// commands that do not specify their own.
 type shellGroup struct {
-	Commands     []*shellOperation `bson:"commands" json:"commands" yaml:"commands"`
-	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
-	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+	Commands             []*shellOperation `bson:"commands" json:"commands" yaml:"commands"`
+	Environment          map[string]string `bson:"environment" json:"environment" yaml:"environment"`
+	EnvironmentMode      string            `bson:"environment_mode" json:"environment_mode" yaml:"environment_mode"`
+	EnvironmentAllowlist []string          `bson:"environment_allowlist" json:"environment_allowlist" yaml:"environment_allowlist"`
+	RunAs                *runAsSpec        `bson:"run_as" json:"run_as" yaml:"run_as"`
+	Limits               *processLimits    `bson:"limits" json:"limits" yaml:"limits"`
+	Requirements         GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	*Base                `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *shellGroup) applyDefaults(cmd *shellOperation) {
+	if cmd.RunAs == nil {
+		cmd.RunAs = c.RunAs
+	}
+
+	if cmd.Limits == nil {
+		cmd.Limits = c.Limits
+	}
+
+	if cmd.EnvironmentMode == "" {
+		cmd.EnvironmentMode = c.EnvironmentMode
+	}
+
+	if len(cmd.EnvironmentAllowlist) == 0 && cmd.EnvironmentMode == "allowlist" {
+		cmd.EnvironmentAllowlist = c.EnvironmentAllowlist
+	}
+
+	if len(c.Environment) == 0 {
+		return
+	}
+
+	env := make(map[string]string, len(c.Environment)+len(cmd.Environment))
+	for key, value := range c.Environment {
+		env[key] = value
+	}
+	for key, value := range cmd.Environment {
+		env[key] = value
+	}
+	cmd.Environment = env
 }
 
 func (c *shellGroup) Run() {
@@ -51,55 +93,15 @@ func (c *shellGroup) Run() {
 		return
 	}
 
-	var success []*greenbay.CheckOutput
-	var failure []*greenbay.CheckOutput
-
+	checks := make([]greenbay.Checker, 0, len(c.Commands))
 	for idx, cmd := range c.Commands {
 		if cmd.Base == nil {
 			cmd.Base = NewBase(fmt.Sprintf("%s-%d", c.ID(), idx), c.Type().Version)
 		}
 
-		cmd.Run()
-
-		result := cmd.Output()
-		if result.Passed {
-			success = append(success, &result)
-		} else {
-			failure = append(failure, &result)
-		}
+		c.applyDefaults(cmd)
+		checks = append(checks, cmd)
 	}
 
-	result, err := c.Requirements.GetResults(len(success), len(failure))
-	c.setState(result)
-	c.AddError(err)
-	grip.Debugf("task '%s' received result %t, with %d successes and %d failures",
-		c.ID(), result, len(success), len(failure))
-
-	if !result {
-		var output []string
-		var errs []string
-		var printableResults []*greenbay.CheckOutput
-
-		if c.Requirements.None {
-			printableResults = success
-		} else if c.Requirements.Any || c.Requirements.One {
-			printableResults = success
-			printableResults = append(printableResults, failure...)
-		} else {
-			printableResults = failure
-		}
-
-		for _, cmd := range printableResults {
-			if cmd.Message != "" {
-				output = append(output, cmd.Message)
-			}
-
-			if cmd.Error != "" {
-				errs = append(errs, cmd.Error)
-			}
-		}
-
-		c.setMessage(output)
-		c.AddError(errors.New(strings.Join(errs, "\n")))
-	}
+	c.runGroupChecks(c.Requirements, checks)
 }
@@ -1,8 +1,10 @@
 package check
 
 import (
+	"os"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -207,3 +209,90 @@ func (s *CmdGroupSuite) TestWithNoneRequiredSucceedToFail() {
 	output := s.check.Output()
 	s.False(output.Passed)
 }
+
+func TestCmdGroupEnvironmentDefaults(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	require.NoError(os.Setenv("GREENBAY_TEST_INHERITED", "inherited"))
+	defer os.Unsetenv("GREENBAY_TEST_INHERITED")
+
+	check := &shellGroup{
+		Environment:     map[string]string{"VALUE": "group", "OTHER": "group"},
+		EnvironmentMode: "merge",
+		Requirements:    GroupRequirements{Name: "cmd-group", All: true},
+		Commands: []*shellOperation{
+			makeShellJob(`test "$VALUE" = group && command -v ls`, "", true, nil),
+			makeShellJob(`test "$VALUE" = command && test "$OTHER" = group`, "", true,
+				map[string]string{"VALUE": "command"}),
+			{
+				Command:         `test -z "$GREENBAY_TEST_INHERITED" && test "$VALUE" = group`,
+				EnvironmentMode: "replace",
+			},
+		},
+		Base: NewBase("cmd-group", 0),
+	}
+
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, "%s", output.Message)
+	assert.NoError(check.Error())
+}
+
+func TestCmdGroupEnvironmentModeOverrides(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	require.NoError(os.Setenv("GREENBAY_TEST_ALLOWED", "allowed"))
+	defer os.Unsetenv("GREENBAY_TEST_ALLOWED")
+	require.NoError(os.Setenv("GREENBAY_TEST_INHERITED", "inherited"))
+	defer os.Unsetenv("GREENBAY_TEST_INHERITED")
+
+	// only commands that use the allowlist mode inherit the
+	// group's allowlist.
+	check := &shellGroup{
+		Environment:          map[string]string{"VALUE": "group"},
+		EnvironmentMode:      "allowlist",
+		EnvironmentAllowlist: []string{"GREENBAY_TEST_ALLOWED"},
+		Requirements:         GroupRequirements{Name: "cmd-group", All: true},
+		Commands: []*shellOperation{
+			{Command: `test "$GREENBAY_TEST_ALLOWED" = allowed && test -z "$GREENBAY_TEST_INHERITED"`},
+			{
+				Command:         `test "$GREENBAY_TEST_INHERITED" = inherited && test "$VALUE" = group`,
+				EnvironmentMode: "merge",
+			},
+			{
+				Command:         `test -z "$GREENBAY_TEST_ALLOWED" && test "$VALUE" = group`,
+				EnvironmentMode: "replace",
+			},
+		},
+		Base: NewBase("cmd-group", 0),
+	}
+
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, "%s", output.Message)
+	assert.NoError(check.Error())
+	assert.Equal([]string{"GREENBAY_TEST_ALLOWED"}, check.Commands[0].EnvironmentAllowlist)
+	assert.Empty(check.Commands[1].EnvironmentAllowlist)
+	assert.Empty(check.Commands[2].EnvironmentAllowlist)
+}
+
+func TestCmdGroupArgvAndExecForms(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &shellGroup{
+		Requirements: GroupRequirements{Name: "cmd-group", All: true},
+		Commands: []*shellOperation{
+			{Argv: []string{"test", "a b", "=", "a b"}},
+			{Exec: `test "a b" = 'a b'`},
+			{Command: "true"},
+		},
+		Base: NewBase("cmd-group", 0),
+	}
+
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, "%s", output.Message)
+	assert.NoError(check.Error())
+}
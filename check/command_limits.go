@@ -0,0 +1,342 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// LimitHelperCommand is the name of the hidden greenbay subcommand
+// that sets the CPU time, address space, and open file limits of a
+// command and then executes it, which greenbay runs to start commands
+// with those limits. The subcommand calls RunLimitHelper.
+const LimitHelperCommand = "limit-helper"
+
+// processLimits bound the resources that the subprocesses of a check
+// may use. CPUTime is a duration (e.g. "30s"), rounded up to whole
+// seconds. AddressSpace and Output are sizes in bytes, with an
+// optional K, M, or G suffix (e.g. "512M"). OpenFiles is a number of
+// file descriptors.
+//
+// CPU time, address space, and open file limits are set with
+// setrlimit in the child before it executes the command, without a
+// shell, and are not supported on windows. Commands that run out of CPU time are killed,
+// while commands that exceed the address space or open file limits see
+// allocations or opens fail, and typically exit with an error. Output
+// limits the combined size of the command's stdout and stderr:
+// commands that write more are killed.
+type processLimits struct {
+	CPUTime      string `bson:"cpu_time" json:"cpu_time" yaml:"cpu_time"`
+	AddressSpace string `bson:"address_space" json:"address_space" yaml:"address_space"`
+	OpenFiles    uint64 `bson:"open_files" json:"open_files" yaml:"open_files"`
+	Output       string `bson:"output" json:"output" yaml:"output"`
+}
+
+// commandLimits are validated processLimits, along with the
+// wall-clock deadline for each command.
+type commandLimits struct {
+	cpuSeconds   uint64
+	addressSpace uint64
+	openFiles    uint64
+	output       int64
+	timeout      time.Duration
+}
+
+// parseByteSize parses a size in bytes, with an optional K, M, or G
+// (binary) suffix.
+func parseByteSize(size string) (uint64, error) {
+	size = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+
+	multiplier := uint64(1)
+	for idx, suffix := range []string{"K", "M", "G"} {
+		if strings.HasSuffix(size, suffix) {
+			multiplier = 1 << (10 * uint(idx+1))
+			size = strings.TrimSuffix(size, suffix)
+			break
+		}
+	}
+
+	value, err := strconv.ParseUint(size, 10, 64)
+	if err != nil || value == 0 {
+		return 0, errors.Errorf("'%s' is not a valid size", size)
+	}
+
+	return value * multiplier, nil
+}
+
+// resolve validates the limits and combines them with the check's
+// timeout. Commands with a CPU time limit and no timeout have a
+// wall-clock deadline of twice the CPU time, so that commands that
+// block without using CPU time still end.
+func (l *processLimits) resolve(timeout time.Duration) (*commandLimits, error) {
+	out := &commandLimits{timeout: timeout}
+	if l == nil {
+		return out, nil
+	}
+
+	catcher := grip.NewCatcher()
+
+	if l.CPUTime != "" {
+		cpu, err := time.ParseDuration(l.CPUTime)
+		if err != nil || cpu <= 0 {
+			catcher.Add(errors.Errorf("cpu time limit '%s' is not a positive duration", l.CPUTime))
+		} else {
+			out.cpuSeconds = uint64((cpu + time.Second - 1) / time.Second)
+			if out.timeout == 0 {
+				out.timeout = 2 * time.Duration(out.cpuSeconds) * time.Second
+			}
+		}
+	}
+
+	if l.AddressSpace != "" {
+		size, err := parseByteSize(l.AddressSpace)
+		catcher.Add(errors.Wrap(err, "invalid address space limit"))
+		out.addressSpace = size
+	}
+
+	out.openFiles = l.OpenFiles
+
+	if l.Output != "" {
+		size, err := parseByteSize(l.Output)
+		catcher.Add(errors.Wrap(err, "invalid output limit"))
+		out.output = int64(size)
+	}
+
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	if err := checkLimitsSupported(out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// rlimits reports if the limits include any that are set with
+// setrlimit.
+func (l *commandLimits) rlimits() bool {
+	return l != nil && (l.cpuSeconds > 0 || l.addressSpace > 0 || l.openFiles > 0)
+}
+
+func (l *commandLimits) isSet() bool {
+	return l.rlimits() || (l != nil && (l.output > 0 || l.timeout > 0))
+}
+
+// limitError reports that a command exceeded one of its limits.
+type limitError struct {
+	description string
+}
+
+func (e *limitError) Error() string { return e.description }
+
+// limitSetupError reports that greenbay could not set the limits of a
+// command, which did not run.
+type limitSetupError struct {
+	description string
+}
+
+func (e *limitSetupError) Error() string { return e.description }
+
+// outputBudget is shared by the stdout and stderr of a command with
+// an output limit, and closes exceeded when the command writes more
+// than the limit.
+type outputBudget struct {
+	remaining int64
+	exceeded  chan struct{}
+	once      sync.Once
+	mutex     sync.Mutex
+}
+
+// limitedWriter writes to the underlying writer until the command
+// exhausts its output budget, and discards all output after that.
+type limitedWriter struct {
+	budget *outputBudget
+	writer io.Writer
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	w.budget.mutex.Lock()
+	n := int64(len(p))
+	if n > w.budget.remaining {
+		n = w.budget.remaining
+	}
+	w.budget.remaining -= n
+	w.budget.mutex.Unlock()
+
+	if n < int64(len(p)) {
+		w.budget.once.Do(func() { close(w.budget.exceeded) })
+	}
+
+	if n > 0 {
+		if _, err := w.writer.Write(p[:n]); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// runCommand starts the command and waits for it to exit, applying
+// the limits. Commands that run longer than the timeout, or write
+// more than the output limit, are killed along with all of their
+// children. When a command exceeds a limit, runCommand returns a
+// *limitError.
+func runCommand(cmd *exec.Cmd, limits *commandLimits) error {
+	if limits == nil {
+		limits = &commandLimits{}
+	}
+
+	var exceeded chan struct{}
+	if limits.output > 0 {
+		budget := &outputBudget{remaining: limits.output, exceeded: make(chan struct{})}
+		exceeded = budget.exceeded
+
+		if cmd.Stdout != nil {
+			cmd.Stdout = &limitedWriter{budget: budget, writer: cmd.Stdout}
+		}
+		if cmd.Stderr != nil {
+			cmd.Stderr = &limitedWriter{budget: budget, writer: cmd.Stderr}
+		}
+	}
+
+	setProcessGroup(cmd)
+	limitsApplied, err := applyLimits(cmd, limits)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Start()
+	if limitsApplied != nil {
+		if setupErr := limitsApplied(); setupErr != nil && err == nil {
+			_ = cmd.Wait()
+			return setupErr
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var timer <-chan time.Time
+	if limits.timeout > 0 {
+		t := time.NewTimer(limits.timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case err = <-done:
+		if limitErr := limitExceeded(err, limits); limitErr != nil {
+			return limitErr
+		}
+		return err
+	case <-timer:
+		err = &limitError{fmt.Sprintf("timed out after %s", limits.timeout)}
+	case <-exceeded:
+		err = &limitError{fmt.Sprintf("exceeded the output limit of %d bytes", limits.output)}
+	}
+
+	grip.Warning(errors.Wrapf(killProcessGroup(cmd), "problem killing '%s'", strings.Join(cmd.Args, " ")))
+	<-done
+
+	return err
+}
+
+// defaultCommandTimeout and defaultCommandOutput bound the commands
+// that checks run to inspect the system, like package managers and
+// version queries, unless the check configures its own limits.
+const (
+	defaultCommandTimeout = 5 * time.Minute
+	defaultCommandOutput  = 64 * 1024 * 1024
+)
+
+// commandOptions are the settings that checks apply to each
+// subprocess that they start: the user to run as, and limits.
+type commandOptions struct {
+	identity *processIdentity
+	limits   *commandLimits
+}
+
+// combinedOutput runs the command with the options, and returns its
+// combined stdout and stderr.
+func (o commandOptions) combinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	o.identity.configure(cmd)
+
+	out := newCappedBuffer()
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err := runCommand(cmd, o.limits)
+
+	return []byte(out.String()), err
+}
+
+// resolveCommandOptions resolves the user described by spec and the
+// limits, along with the timeout, a duration (e.g. "30s"), for checks
+// that run commands.
+func resolveCommandOptions(spec *runAsSpec, limits *processLimits, timeout string) (commandOptions, error) {
+	var deadline time.Duration
+	if timeout != "" {
+		var err error
+		deadline, err = time.ParseDuration(timeout)
+		if err != nil || deadline <= 0 {
+			return commandOptions{}, errors.Errorf("timeout '%s' is not a positive duration", timeout)
+		}
+	}
+
+	resolved, err := limits.resolve(deadline)
+	if err != nil {
+		return commandOptions{}, err
+	}
+
+	identity, err := spec.resolve()
+	if err != nil {
+		return commandOptions{}, err
+	}
+
+	return commandOptions{identity: identity, limits: resolved}, nil
+}
+
+// withDefaultLimits returns options with the default timeout and
+// output limit, unless the options already set them.
+func (o commandOptions) withDefaultLimits() commandOptions {
+	limits := commandLimits{}
+	if o.limits != nil {
+		limits = *o.limits
+	}
+
+	if limits.timeout == 0 {
+		limits.timeout = defaultCommandTimeout
+	}
+
+	if limits.output == 0 {
+		limits.output = defaultCommandOutput
+	}
+
+	o.limits = &limits
+	return o
+}
+
+// output runs the command with the options, and returns its stdout.
+func (o commandOptions) output(cmd *exec.Cmd) ([]byte, error) {
+	o.identity.configure(cmd)
+
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+
+	err := runCommand(cmd, o.limits)
+
+	return out.Bytes(), err
+}
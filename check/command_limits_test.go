@@ -0,0 +1,120 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain runs the test binary as the limit helper when checks run
+// commands with limits, as the greenbay binary does.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == LimitHelperCommand {
+		err := RunLimitHelper(os.Args[2:])
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(127)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestParseByteSize(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]uint64{
+		"1":      1,
+		"512":    512,
+		"4K":     4096,
+		"4kb":    4096,
+		"512M":   512 * 1024 * 1024,
+		" 2G ":   2 * 1024 * 1024 * 1024,
+		"1024KB": 1024 * 1024,
+	}
+
+	for size, expected := range cases {
+		value, err := parseByteSize(size)
+		assert.NoError(err, size)
+		assert.Equal(expected, value, size)
+	}
+
+	for _, size := range []string{"", "0", "-1", "1T", "M", "1.5G"} {
+		_, err := parseByteSize(size)
+		assert.Error(err, size)
+	}
+}
+
+func TestProcessLimitsResolution(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var limits *processLimits
+	resolved, err := limits.resolve(time.Second)
+	require.NoError(err)
+	assert.False(resolved.rlimits())
+	assert.True(resolved.isSet())
+	assert.Equal(time.Second, resolved.timeout)
+
+	resolved, err = (&processLimits{Output: "1K"}).resolve(0)
+	require.NoError(err)
+	assert.Equal(int64(1024), resolved.output)
+	assert.Equal(time.Duration(0), resolved.timeout)
+
+	for _, l := range []*processLimits{
+		{CPUTime: "forever"},
+		{CPUTime: "-1s"},
+		{AddressSpace: "lots"},
+		{Output: "0"},
+	} {
+		_, err = l.resolve(0)
+		assert.Error(err)
+	}
+}
+
+func TestCommandOptionsDefaultLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	options := commandOptions{}.withDefaultLimits()
+	assert.Equal(defaultCommandTimeout, options.limits.timeout)
+	assert.Equal(int64(defaultCommandOutput), options.limits.output)
+
+	// configured limits take precedence over the defaults, and are
+	// not modified.
+	configured := &commandLimits{timeout: time.Second, output: 1024, openFiles: 64}
+	options = commandOptions{limits: configured}.withDefaultLimits()
+	assert.Equal(time.Second, options.limits.timeout)
+	assert.Equal(int64(1024), options.limits.output)
+	assert.Equal(uint64(64), options.limits.openFiles)
+
+	configured = &commandLimits{openFiles: 64}
+	options = commandOptions{limits: configured}.withDefaultLimits()
+	assert.Equal(defaultCommandTimeout, options.limits.timeout)
+	assert.Equal(time.Duration(0), configured.timeout)
+}
+
+func TestTruncateMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("short", truncateMessage("short"))
+
+	long := strings.Repeat("a", maxMessageSize+10)
+	truncated := truncateMessage(long)
+	assert.True(strings.HasPrefix(truncated, strings.Repeat("a", maxMessageSize)))
+	assert.True(strings.HasSuffix(truncated, "[... truncated 10 bytes]"))
+
+	// truncation does not split multi-byte characters
+	long = strings.Repeat("a", maxMessageSize-1) + strings.Repeat("ü", 10)
+	truncated = truncateMessage(long)
+	assert.True(strings.HasSuffix(truncated, strings.Repeat("a", maxMessageSize-1)+"\n[... truncated 20 bytes]"))
+
+	check := &shellOperation{
+		Argv: []string{"go", "env"},
+		Base: NewBase("shell-operation", 0),
+	}
+	check.setMessage(long)
+	assert.Len(check.Output().Message, len(truncated))
+}
@@ -0,0 +1,218 @@
+// +build linux freebsd solaris darwin
+
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// helperResourceLimits maps the names of limits that the limit helper
+// sets to resources and descriptions.
+var helperResourceLimits = map[string]struct {
+	resource    int
+	description string
+}{
+	"cpu":    {syscall.RLIMIT_CPU, "cpu time"},
+	"as":     {syscall.RLIMIT_AS, "address space"},
+	"nofile": {syscall.RLIMIT_NOFILE, "open file"},
+}
+
+// RunLimitHelper implements the LimitHelperCommand subcommand. Its
+// arguments are the limits (e.g. "cpu=10:11,nofile=64:64", as soft
+// and hard limits), the file descriptor on which to report errors,
+// the path of the command, and the command's arguments. It sets the
+// limits and executes the command, and only returns if it cannot,
+// after reporting the error.
+func RunLimitHelper(args []string) error {
+	if len(args) < 4 {
+		return errors.Errorf("%s requires limits, a file descriptor, and a command", LimitHelperCommand)
+	}
+
+	fd, err := strconv.Atoi(args[1])
+	if err != nil || fd < 3 {
+		return errors.Errorf("'%s' is not a file descriptor for limit errors", args[1])
+	}
+
+	err = setHelperLimits(args[0])
+	if err == nil {
+		syscall.CloseOnExec(fd)
+		err = errors.Wrapf(syscall.Exec(args[2], args[3:], os.Environ()), "problem executing '%s'", args[2])
+	}
+
+	report := os.NewFile(uintptr(fd), "limit-errors")
+	fmt.Fprint(report, err.Error())
+	report.Close()
+
+	return err
+}
+
+func setHelperLimits(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		var name string
+		var soft, hard uint64
+		if _, err := fmt.Sscanf(strings.Replace(item, "=", " ", 1), "%s %d:%d", &name, &soft, &hard); err != nil {
+			return errors.Errorf("limit '%s' is not valid", item)
+		}
+
+		limit, ok := helperResourceLimits[name]
+		if !ok {
+			return errors.Errorf("limit '%s' is not supported", name)
+		}
+
+		if err := syscall.Setrlimit(limit.resource, newRlimit(soft, hard)); err != nil {
+			return errors.Wrapf(err, "problem setting the %s limit to %d", limit.description, soft)
+		}
+	}
+
+	return nil
+}
+
+// checkHelperAccess returns a *limitSetupError if the user in the
+// credential cannot execute the greenbay binary, because it lacks
+// permission to execute the file or to search one of its directories.
+func checkHelperAccess(helper string, cred *syscall.Credential) error {
+	if cred == nil || cred.Uid == 0 {
+		return nil
+	}
+
+	for path := helper; ; path = filepath.Dir(path) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return &limitSetupError{fmt.Sprintf("problem checking access to greenbay at '%s' to set limits: %s",
+				helper, err)}
+		}
+
+		if !canExecute(info, cred) {
+			return &limitSetupError{fmt.Sprintf("uid=%d cannot execute greenbay at '%s', which sets "+
+				"the limits of commands before running them; install greenbay where that user can execute it",
+				cred.Uid, helper)}
+		}
+
+		if parent := filepath.Dir(path); parent == path {
+			return nil
+		}
+	}
+}
+
+// canExecute reports if the user in the credential can execute the
+// file, or search the directory, using its permission bits.
+func canExecute(info os.FileInfo, cred *syscall.Credential) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+
+	mode := info.Mode().Perm()
+	if stat.Uid == cred.Uid {
+		return mode&0100 != 0
+	}
+
+	if stat.Gid == cred.Gid {
+		return mode&0010 != 0
+	}
+	for _, gid := range cred.Groups {
+		if stat.Gid == gid {
+			return mode&0010 != 0
+		}
+	}
+
+	return mode&0001 != 0
+}
+
+func checkLimitsSupported(limits *commandLimits) error { return nil }
+
+// applyLimits runs the command with greenbay's LimitHelperCommand,
+// which sets the limits and executes the command, so that commands
+// with limits do not require a shell. The hard CPU time limit is a
+// second past the soft limit, so that the command receives SIGXCPU,
+// which identifies the cause of its exit. applyLimits returns a
+// *limitSetupError if the user that the command runs as cannot
+// execute greenbay, and otherwise a function to call after starting
+// the command, which returns a *limitSetupError if the helper could
+// not set the limits or execute the command.
+func applyLimits(cmd *exec.Cmd, limits *commandLimits) (func() error, error) {
+	if !limits.rlimits() || cmd.Err != nil {
+		return nil, nil
+	}
+
+	helper, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "problem finding the greenbay executable to set limits")
+	}
+
+	if cmd.SysProcAttr != nil {
+		if err = checkHelperAccess(helper, cmd.SysProcAttr.Credential); err != nil {
+			return nil, err
+		}
+	}
+
+	var spec []string
+	if limits.cpuSeconds > 0 {
+		spec = append(spec, fmt.Sprintf("cpu=%d:%d", limits.cpuSeconds, limits.cpuSeconds+1))
+	}
+
+	if limits.addressSpace > 0 {
+		spec = append(spec, fmt.Sprintf("as=%d:%d", limits.addressSpace, limits.addressSpace))
+	}
+
+	if limits.openFiles > 0 {
+		spec = append(spec, fmt.Sprintf("nofile=%d:%d", limits.openFiles, limits.openFiles))
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "problem creating pipe for limit errors")
+	}
+
+	cmd.Args = append([]string{"greenbay", LimitHelperCommand, strings.Join(spec, ","),
+		strconv.Itoa(3 + len(cmd.ExtraFiles)), cmd.Path}, cmd.Args...)
+	cmd.ExtraFiles = append(cmd.ExtraFiles, writer)
+	cmd.Path = helper
+
+	return func() error {
+		writer.Close()
+		defer reader.Close()
+
+		msg, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return errors.Wrap(err, "problem reading limit errors")
+		}
+
+		if len(msg) > 0 {
+			return &limitSetupError{string(msg)}
+		}
+
+		return nil
+	}, nil
+}
+
+// limitExceeded returns a *limitError if the command was killed
+// after using all of its CPU time.
+func limitExceeded(err error, limits *commandLimits) error {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || limits.cpuSeconds == 0 {
+		return nil
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return nil
+	}
+
+	used := exitErr.UserTime() + exitErr.SystemTime()
+	if status.Signal() == syscall.SIGXCPU || used >= time.Duration(limits.cpuSeconds)*time.Second {
+		return &limitError{fmt.Sprintf("exceeded the cpu time limit of %ds", limits.cpuSeconds)}
+	}
+
+	return nil
+}
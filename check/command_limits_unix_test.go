@@ -0,0 +1,272 @@
+// +build linux freebsd solaris darwin
+
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandCheckLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &shellOperation{
+		Command: "while :; do :; done",
+		Limits:  &processLimits{CPUTime: "1s"},
+		Base:    NewBase("shell-operation", 0),
+	}
+	start := time.Now()
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "exceeded the cpu time limit of 1s")
+	assert.True(time.Since(start) < 10*time.Second)
+
+	check = &shellOperation{
+		Command: "sleep 10",
+		Limits:  &processLimits{CPUTime: "1s"},
+		Base:    NewBase("shell-operation", 0),
+	}
+	start = time.Now()
+	check.Run()
+	output = check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "timed out after 2s")
+	assert.True(time.Since(start) < 5*time.Second)
+
+	check = &shellOperation{
+		Command: "yes",
+		Limits:  &processLimits{Output: "4K"},
+		Base:    NewBase("shell-operation", 0),
+	}
+	check.Run()
+	output = check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "exceeded the output limit of 4096 bytes")
+	assert.True(len(output.Message) < 5000)
+
+	check = &shellOperation{
+		Argv:   []string{"sh", "-c", "ulimit -n; ulimit -v"},
+		Limits: &processLimits{OpenFiles: 64, AddressSpace: "1G"},
+		Stdout: outputAssertions{Matches: []string{`^64\n1048576$`}},
+		Base:   NewBase("shell-operation", 0),
+	}
+	check.Run()
+	output = check.Output()
+	assert.True(output.Passed, output.Message)
+}
+
+func TestCompileCheckLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &compileCheck{
+		Source:        "sleep 10",
+		Timeout:       "100ms",
+		Base:          NewBase("compile-and-run-sh-script", 0),
+		shouldRunCode: true,
+		compiler:      compileScript{bin: "/bin/sh"},
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "timed out after 100ms")
+
+	check = &compileCheck{
+		Source:        "ulimit -n",
+		Limits:        &processLimits{OpenFiles: 32},
+		Base:          NewBase("compile-and-run-sh-script", 0),
+		shouldRunCode: true,
+		compiler:      compileScript{bin: "/bin/sh"},
+	}
+	check.Run()
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	program := &programOutputCheck{
+		Source:         "ulimit -n",
+		ExpectedOutput: "32",
+		Limits:         &processLimits{OpenFiles: 32},
+		Base:           NewBase("run-program-system-sh", 0),
+		compiler:       compileScript{bin: "/bin/sh"},
+	}
+	program.Run()
+	assert.True(program.Output().Passed, program.Output().Message)
+}
+
+func TestProgramVersionLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &programVersion{
+		Command: "sh",
+		Args:    []string{"-c", "sleep 5; echo 1.0"},
+		Timeout: "100ms",
+		Version: "1.0",
+		Base:    NewBase("program-version", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "timed out after 100ms")
+
+	check = &programVersion{
+		Command: "sh",
+		Args:    []string{"-c", "echo 1.0; exec yes"},
+		Limits:  &processLimits{Output: "1K"},
+		Version: "1.0",
+		Base:    NewBase("program-version", 0),
+	}
+	check.Run()
+	output = check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "exceeded the output limit of 1024 bytes")
+
+	check = &programVersion{
+		Command: "echo",
+		Args:    []string{"1.0"},
+		Timeout: "soon",
+		Version: "1.0",
+		Base:    NewBase("program-version", 0),
+	}
+	check.Run()
+	output = check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "timeout 'soon' is not a positive duration")
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	check = &programVersion{
+		Command:      "cat",
+		Args:         []string{"/proc/self/limits"},
+		Pattern:      `Max open files\s+(\d+)`,
+		Limits:       &processLimits{OpenFiles: 64},
+		Version:      "64",
+		Relationship: "eq",
+		Base:         NewBase("program-version", 0),
+	}
+	check.Run()
+	output = check.Output()
+	assert.True(output.Passed, output.Message)
+}
+
+func TestCommandLimitsWithoutShell(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("reading the limits of a process requires /proc")
+	}
+
+	assert := assert.New(t)
+
+	// run cat from a PATH that only has cat, so that running the
+	// command with a shell would fail.
+	cat, err := exec.LookPath("cat")
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "greenbay-path-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, os.Symlink(cat, filepath.Join(dir, "cat")))
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	require.NoError(t, os.Setenv("PATH", dir))
+	_, err = exec.LookPath("sh")
+	require.Error(t, err)
+
+	check := &shellOperation{
+		Argv:   []string{"cat", "/proc/self/limits"},
+		Limits: &processLimits{CPUTime: "5s", AddressSpace: "1G", OpenFiles: 64},
+		Stdout: outputAssertions{Matches: []string{
+			`Max cpu time\s+5\s+6\s`,
+			`Max address space\s+1073741824\s+1073741824\s`,
+			`Max open files\s+64\s+64\s`,
+		}},
+		Base: NewBase("shell-operation", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, output.Message)
+
+	// the command runs with greenbay as a helper, not with a shell.
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	cmd := exec.Command("cat")
+	limitsApplied, err := applyLimits(cmd, &commandLimits{openFiles: 64})
+	require.NoError(t, err)
+	assert.Equal(executable, cmd.Path)
+	assert.Equal([]string{"greenbay", LimitHelperCommand, "nofile=64:64", "3", filepath.Join(dir, "cat"), "cat"}, cmd.Args)
+	assert.NoError(limitsApplied())
+
+	// limits that greenbay cannot set fail the check, even when the
+	// check expects the command to fail.
+	check = &shellOperation{
+		Argv:       []string{"cat", "/proc/self/limits"},
+		Limits:     &processLimits{OpenFiles: 1 << 40},
+		Base:       NewBase("shell-operation-error", 0),
+		shouldFail: true,
+	}
+	check.Run()
+	output = check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "did not run: problem setting the open file limit to 1099511627776")
+	assert.NotContains(output.Message, "Max open files")
+}
+
+func TestLimitHelperAccess(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-helper-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	helper := filepath.Join(dir, "greenbay")
+	require.NoError(t, ioutil.WriteFile(helper, []byte("#!/bin/sh\n"), 0700))
+
+	info, err := os.Stat(helper)
+	require.NoError(t, err)
+	stat := info.Sys().(*syscall.Stat_t)
+	other := &syscall.Credential{Uid: stat.Uid + 1, Gid: stat.Gid + 1}
+
+	// root and the owner can execute the helper.
+	assert.NoError(checkHelperAccess(helper, nil))
+	assert.NoError(checkHelperAccess(helper, &syscall.Credential{Uid: 0}))
+	if stat.Uid != 0 {
+		assert.NoError(checkHelperAccess(helper, &syscall.Credential{Uid: stat.Uid, Gid: stat.Gid}))
+	}
+
+	// other users cannot execute the helper, or search its
+	// directory.
+	err = checkHelperAccess(helper, other)
+	require.Error(t, err)
+	assert.IsType(&limitSetupError{}, err)
+	assert.Contains(err.Error(), fmt.Sprintf("uid=%d cannot execute greenbay at '%s'", other.Uid, helper))
+
+	require.NoError(t, os.Chmod(helper, 0755))
+	assert.Error(checkHelperAccess(helper, other))
+	require.NoError(t, os.Chmod(dir, 0755))
+	assert.NoError(checkHelperAccess(helper, other))
+
+	// group members can execute the helper with group permission.
+	require.NoError(t, os.Chmod(helper, 0750))
+	assert.Error(checkHelperAccess(helper, other))
+	assert.NoError(checkHelperAccess(helper, &syscall.Credential{Uid: other.Uid, Gid: other.Gid, Groups: []uint32{stat.Gid}}))
+
+	// commands that run as a user who cannot execute greenbay do
+	// not run.
+	executable, err := os.Executable()
+	require.NoError(t, err)
+	if err = checkHelperAccess(executable, other); err == nil {
+		t.Skip("the test binary is executable by all users")
+	}
+	cmd := exec.Command("cat")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: other}
+	_, err = applyLimits(cmd, &commandLimits{openFiles: 64})
+	assert.IsType(&limitSetupError{}, err)
+}
@@ -0,0 +1,29 @@
+// +build windows
+
+package check
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func checkLimitsSupported(limits *commandLimits) error {
+	if limits.rlimits() {
+		return errors.Errorf("cpu time, address space, and open file limits are not supported on this platform (%s)",
+			runtime.GOOS)
+	}
+
+	return nil
+}
+
+// RunLimitHelper returns an error, because greenbay cannot set
+// limits on this platform.
+func RunLimitHelper(args []string) error {
+	return errors.Errorf("%s is not supported on this platform (%s)", LimitHelperCommand, runtime.GOOS)
+}
+
+func applyLimits(cmd *exec.Cmd, limits *commandLimits) (func() error, error) { return nil, nil }
+
+func limitExceeded(err error, limits *commandLimits) error { return nil }
@@ -1,9 +1,15 @@
 package check
 
 import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCommandCheck(t *testing.T) {
@@ -55,3 +61,313 @@ func TestCommandCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestCommandCheckTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process group tests require a unix shell")
+	}
+
+	assert := assert.New(t)
+
+	// the background process holds the output pipes open, so the
+	// check only returns promptly if the entire process group is
+	// killed.
+	check := &shellOperation{
+		Command: "sleep 10 & sleep 10",
+		Timeout: "100ms",
+		Base:    NewBase("cmd", 0),
+	}
+
+	start := time.Now()
+	check.Run()
+	assert.True(time.Since(start) < 5*time.Second)
+
+	output := check.Output()
+	assert.True(output.Completed)
+	assert.False(output.Passed)
+	assert.Contains(output.Error, "timed out after 100ms")
+	assert.Contains(output.Message, "timed out")
+
+	// timeouts fail checks that expect errors, too
+	check = &shellOperation{
+		Command:    "sleep 10",
+		Timeout:    "100ms",
+		shouldFail: true,
+		Base:       NewBase("cmd", 0),
+	}
+	check.Run()
+	assert.False(check.Output().Passed)
+
+	check = &shellOperation{
+		Command: "true",
+		Timeout: "10s",
+		Base:    NewBase("cmd", 0),
+	}
+	check.Run()
+	assert.True(check.Output().Passed)
+
+	for _, timeout := range []string{"ten seconds", "-1s", "0s"} {
+		check = &shellOperation{
+			Command: "true",
+			Timeout: timeout,
+			Base:    NewBase("cmd", 0),
+		}
+		check.Run()
+		assert.False(check.Output().Passed, timeout)
+		assert.Error(check.Error(), timeout)
+	}
+}
+
+func TestCommandCheckExitCodes(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		command    string
+		codes      []int
+		shouldFail bool
+		passes     bool
+	}{
+		{"exit 3", []int{3}, false, true},
+		{"exit 3", []int{0, 1}, false, false},
+		{"exit 0", []int{0, 1}, false, true},
+		{"exit 1", []int{0, 1}, false, true},
+		{"exit 0", nil, true, false},
+		{"exit 2", nil, true, true},
+		{"exit 2", []int{1}, true, false},
+		{"exit 0", []int{0}, true, true},
+	}
+
+	for _, c := range cases {
+		check := &shellOperation{
+			Command:    c.command,
+			ExitCodes:  c.codes,
+			shouldFail: c.shouldFail,
+			Base:       NewBase("cmd", 0),
+		}
+		check.Run()
+
+		output := check.Output()
+		assert.Equal(c.passes, output.Passed, "%+v", c)
+		if c.passes {
+			assert.NoError(check.Error())
+		} else {
+			assert.Error(check.Error())
+		}
+	}
+}
+
+func TestCommandCheckOutputAssertions(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		stdout outputAssertions
+		stderr outputAssertions
+		passes bool
+	}{
+		{outputAssertions{}, outputAssertions{}, true},
+		{outputAssertions{Contains: []string{"hello"}, NotContains: []string{"oops"}},
+			outputAssertions{Matches: []string{"^oo"}}, true},
+		{outputAssertions{Matches: []string{"^hel+o$"}, NotMatches: []string{"[0-9]"}},
+			outputAssertions{Contains: []string{"oops"}}, true},
+		{outputAssertions{Contains: []string{"oops"}}, outputAssertions{}, false},
+		{outputAssertions{}, outputAssertions{NotContains: []string{"oops"}}, false},
+		{outputAssertions{NotMatches: []string{"h.*o"}}, outputAssertions{}, false},
+		{outputAssertions{Matches: []string{"("}}, outputAssertions{}, false},
+	}
+
+	for idx, c := range cases {
+		check := &shellOperation{
+			Command: "echo hello; echo oops >&2",
+			Stdout:  c.stdout,
+			Stderr:  c.stderr,
+			Base:    NewBase("cmd", 0),
+		}
+		check.Run()
+
+		output := check.Output()
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(check.Error())
+		} else {
+			assert.Error(check.Error())
+		}
+	}
+
+	// all failed assertions are reported
+	check := &shellOperation{
+		Command: "echo hello; echo oops >&2",
+		Stdout:  outputAssertions{Contains: []string{"goodbye"}},
+		Stderr:  outputAssertions{NotMatches: []string{"o+ps"}},
+		Base:    NewBase("cmd", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "stdout does not contain 'goodbye'")
+	assert.Contains(output.Message, "stderr matches 'o+ps'")
+}
+
+func TestCommandEnvironmentExpansion(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	require.NoError(os.Setenv("GREENBAY_TEST_BASE", "/usr/bin"))
+	defer os.Unsetenv("GREENBAY_TEST_BASE")
+
+	env := expandEnvironment(map[string]string{
+		"GREENBAY_TEST_BASE": "/opt/foo/bin:$GREENBAY_TEST_BASE",
+		"FOO_HOME":           "/opt/foo",
+		"FOO_LIB":            "${FOO_HOME}/lib",
+		"LITERAL":            "value",
+		"MISSING":            "$GREENBAY_TEST_DOES_NOT_EXIST",
+	})
+
+	assert.Equal("/opt/foo/bin:/usr/bin", env["GREENBAY_TEST_BASE"])
+	assert.Equal("/opt/foo/lib", env["FOO_LIB"])
+	assert.Equal("value", env["LITERAL"])
+	assert.Equal("", env["MISSING"])
+}
+
+func TestCommandEnvironmentModes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	require.NoError(os.Setenv("GREENBAY_TEST_INHERITED", "inherited"))
+	require.NoError(os.Setenv("GREENBAY_TEST_OTHER", "other"))
+	defer os.Unsetenv("GREENBAY_TEST_INHERITED")
+	defer os.Unsetenv("GREENBAY_TEST_OTHER")
+
+	cases := []struct {
+		mode      string
+		allowlist []string
+		command   string
+		passes    bool
+	}{
+		{"", nil, `test -z "$GREENBAY_TEST_INHERITED" && test "$VALUE" = set`, true},
+		{"replace", nil, `test -z "$GREENBAY_TEST_OTHER" && test "$VALUE" = set`, true},
+		{"merge", nil, `test "$GREENBAY_TEST_INHERITED" = inherited && test "$VALUE" = set`, true},
+		{"merge", nil, `command -v ls`, true},
+		{"allowlist", []string{"GREENBAY_TEST_INHERITED"},
+			`test "$GREENBAY_TEST_INHERITED" = inherited && test -z "$GREENBAY_TEST_OTHER" && test "$VALUE" = set`, true},
+		{"invalid", nil, "true", false},
+		{"merge", []string{"GREENBAY_TEST_INHERITED"}, "true", false},
+	}
+
+	for _, c := range cases {
+		check := &shellOperation{
+			Command:              c.command,
+			Environment:          map[string]string{"VALUE": "set"},
+			EnvironmentMode:      c.mode,
+			EnvironmentAllowlist: c.allowlist,
+			Base:                 NewBase("cmd", 0),
+		}
+		check.Run()
+
+		output := check.Output()
+		assert.Equal(c.passes, output.Passed, "%+v: %s", c, output.Message)
+		if c.passes {
+			assert.NoError(check.Error())
+		} else {
+			assert.Error(check.Error())
+		}
+	}
+
+	// an allowlist with no environment only passes the listed variables
+	check := &shellOperation{
+		Command:              `test "$GREENBAY_TEST_OTHER" = other && test -z "$GREENBAY_TEST_INHERITED"`,
+		EnvironmentMode:      "allowlist",
+		EnvironmentAllowlist: []string{"GREENBAY_TEST_OTHER"},
+		Base:                 NewBase("cmd", 0),
+	}
+	check.Run()
+	assert.True(check.Output().Passed)
+
+	// values can extend inherited variables
+	check = &shellOperation{
+		Command:         `test "$GREENBAY_TEST_INHERITED" = "prefix:inherited"`,
+		Environment:     map[string]string{"GREENBAY_TEST_INHERITED": "prefix:$GREENBAY_TEST_INHERITED"},
+		EnvironmentMode: "merge",
+		Base:            NewBase("cmd", 0),
+	}
+	check.Run()
+	assert.True(check.Output().Passed)
+}
+
+func TestCommandCheckArgvAndExecForms(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		check  *shellOperation
+		passes bool
+	}{
+		{&shellOperation{Argv: []string{"true"}}, true},
+		{&shellOperation{Argv: []string{"false"}}, false},
+		{&shellOperation{Argv: []string{"command-does-not-exist"}}, false},
+		{&shellOperation{Argv: []string{"echo", "$HOME; false"},
+			Stdout: outputAssertions{Contains: []string{"$HOME; false"}}}, true},
+		{&shellOperation{Exec: `echo 'single quoted' "double $quoted"`,
+			Stdout: outputAssertions{Matches: []string{`^single quoted double \$quoted$`}}}, true},
+		{&shellOperation{Exec: "sh -c 'exit 3'", ExitCodes: []int{3}}, true},
+		{&shellOperation{Exec: "echo 'unterminated"}, false},
+		{&shellOperation{Exec: "   "}, false},
+		{&shellOperation{Argv: []string{"", "foo"}}, false},
+		{&shellOperation{}, false},
+		{&shellOperation{Command: "true", Argv: []string{"true"}}, false},
+		{&shellOperation{Argv: []string{"true"}, Exec: "true"}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Base = NewBase("cmd", 0)
+		c.check.Run()
+
+		output := c.check.Output()
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(c.check.Error(), "%d", idx)
+		} else {
+			assert.Error(c.check.Error(), "%d", idx)
+		}
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &cappedBuffer{limit: 8}
+	n, err := buf.Write([]byte("hello "))
+	assert.NoError(err)
+	assert.Equal(6, n)
+	n, err = buf.Write([]byte("world"))
+	assert.NoError(err)
+	assert.Equal(5, n)
+	n, err = buf.Write([]byte("!"))
+	assert.NoError(err)
+	assert.Equal(1, n)
+
+	assert.Equal("hello wo", buf.contents())
+	assert.Equal("hello wo\n[... truncated 4 bytes]", buf.String())
+
+	stderr := newCappedBuffer()
+	_, err = stderr.Write([]byte("oops\n"))
+	assert.NoError(err)
+	assert.Equal("[... discarded 4 bytes of stdout]\nhello wo\noops", joinOutput(buf, stderr))
+	assert.Equal("oops", joinOutput(newCappedBuffer(), stderr))
+}
+
+func TestCommandCheckCapturesBoundedOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &shellOperation{
+		Command: "head -c 2000000 /dev/zero | tr '\\0' a; echo oops >&2; exit 1",
+		Stdout:  outputAssertions{Contains: []string{"aaaa"}},
+		Base:    NewBase("shell-operation", 0),
+	}
+	check.Run()
+
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.True(strings.HasPrefix(output.Message, fmt.Sprintf("[... discarded %d bytes of stdout]\naaaa",
+		2000000-maxCapturedOutput)), output.Message[:100])
+	assert.True(len(output.Message) <= maxMessageSize+100)
+}
@@ -0,0 +1,26 @@
+// +build linux freebsd solaris darwin
+
+package check
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the command in its own process group, so that
+// killProcessGroup can stop the command and all of its children.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,17 @@
+// +build windows
+
+package check
+
+import "os/exec"
+
+// process groups are not available on windows, so only the command
+// itself is killed when it times out.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	return cmd.Process.Kill()
+}
@@ -21,6 +21,33 @@ type compiler interface {
 
 type compilerFactory func() compiler
 
+// optionsCompiler is implemented by compilers that can run their
+// commands as another user, and with limits.
+type optionsCompiler interface {
+	withOptions(commandOptions) compiler
+}
+
+// configureCompiler returns a compiler that runs commands as the user
+// described by spec, with the limits and timeout, and the resolved
+// options, whose identity is nil if spec does not describe a user.
+func configureCompiler(c compiler, spec *runAsSpec, limits *processLimits, timeout string) (compiler, commandOptions, error) {
+	options, err := resolveCommandOptions(spec, limits, timeout)
+	if err != nil {
+		return nil, commandOptions{}, err
+	}
+
+	if options.identity == nil && !options.limits.isSet() {
+		return c, options, nil
+	}
+
+	oc, ok := c.(optionsCompiler)
+	if !ok {
+		return nil, commandOptions{}, errors.New("compiler does not support run_as, timeouts, or limits")
+	}
+
+	return oc.withOptions(options), options, nil
+}
+
 func writeTestBody(testBody, ext string) (string, string, error) {
 	testFile, err := ioutil.TempFile(os.TempDir(), "testBody_")
 	if err != nil {
@@ -80,9 +107,12 @@ func registerCompileChecks() {
 }
 
 type compileCheck struct {
-	Source        string   `bson:"source" json:"source" yaml:"source"`
-	Cflags        []string `bson:"cflags" json:"cflags" yaml:"cflags"`
-	CflagsCommand string   `bson:"cflags_command" json:"cflags_command" yaml:"cflags_command"`
+	Source        string         `bson:"source" json:"source" yaml:"source"`
+	Cflags        []string       `bson:"cflags" json:"cflags" yaml:"cflags"`
+	CflagsCommand string         `bson:"cflags_command" json:"cflags_command" yaml:"cflags_command"`
+	Timeout       string         `bson:"timeout" json:"timeout" yaml:"timeout"`
+	RunAs         *runAsSpec     `bson:"run_as" json:"run_as" yaml:"run_as"`
+	Limits        *processLimits `bson:"limits" json:"limits" yaml:"limits"`
 	*Base         `bson:"metadata" json:"metadata" yaml:"metadata"`
 	shouldRunCode bool
 	compiler      compiler
@@ -98,9 +128,18 @@ func (c *compileCheck) Run() {
 		return
 	}
 
+	compiler, options, err := configureCompiler(c.compiler, c.RunAs, c.Limits, c.Timeout)
+	if err != nil {
+		c.setState(false)
+		c.setMessage(err.Error())
+		c.AddError(err)
+		return
+	}
+
 	cflags := []string{}
 	if c.CflagsCommand != "" {
-		output, err := exec.Command("net-snmp-config", "--agent-libs").CombinedOutput()
+		output, err := options.withDefaultLimits().combinedOutput(
+			exec.Command("net-snmp-config", "--agent-libs"))
 		if err != nil {
 			c.setState(false)
 			c.AddError(err)
@@ -115,19 +154,21 @@ func (c *compileCheck) Run() {
 	}
 
 	if c.shouldRunCode {
-		if output, err := c.compiler.CompileAndRun(c.Source, cflags...); err != nil {
+		if output, err := compiler.CompileAndRun(c.Source, cflags...); err != nil {
 			c.setState(false)
 			c.AddError(err)
-			c.setMessage(output)
+			c.setMessage(options.identity.describeOutput(output))
 		} else {
 			c.setState(true)
+			c.setMessage(options.identity.describeOutput(""))
 		}
 	} else {
-		if err := c.compiler.Compile(c.Source, cflags...); err != nil {
+		if err := compiler.Compile(c.Source, cflags...); err != nil {
 			c.setState(false)
 			c.AddError(err)
 		} else {
 			c.setState(true)
 		}
+		c.setMessage(options.identity.describeOutput(""))
 	}
 }
@@ -33,7 +33,8 @@ func compilerInterfaceFactoryTable() map[string]compilerFactory {
 }
 
 type compileGCC struct {
-	bin string
+	bin     string
+	options commandOptions
 }
 
 func gccCompilerAuto() compiler {
@@ -61,6 +62,11 @@ func gccCompilerAuto() compiler {
 	return c
 }
 
+func (c compileGCC) withOptions(options commandOptions) compiler {
+	c.options = options
+	return c
+}
+
 func (c compileGCC) Validate() error {
 	if c.bin == "" {
 		return errors.New("no compiler specified")
@@ -78,12 +84,17 @@ func (c compileGCC) Compile(testBody string, cFlags ...string) error {
 	defer os.Remove(outputName)
 
 	defer grip.CatchWarning(os.Remove(outputName))
+
+	if err = c.options.identity.chown(sourceName); err != nil {
+		return err
+	}
+
 	argv := []string{"-Werror", "-o", outputName, "-c", sourceName}
 	argv = append(argv, cFlags...)
 
 	cmd := exec.Command(c.bin, argv...)
 	grip.Infof("running build command: %s %s", c.bin, strings.Join(cmd.Args, " "))
-	output, err := cmd.CombinedOutput()
+	output, err := c.options.combinedOutput(cmd)
 	if err != nil {
 		return errors.Wrapf(err, "problem compiling test body: %s", string(output))
 	}
@@ -98,19 +109,23 @@ func (c compileGCC) CompileAndRun(testBody string, cFlags ...string) (string, er
 	}
 	defer os.Remove(outputName)
 
+	if err = c.options.identity.chown(sourceName); err != nil {
+		return "", err
+	}
+
 	argv := []string{"-Werror", "-o", outputName, sourceName}
 	argv = append(argv, cFlags...)
 
 	cmd := exec.Command(c.bin, argv...)
 	grip.Infof("running build command: %s %s", c.bin, strings.Join(cmd.Args, " "))
-	out, err := cmd.CombinedOutput()
+	out, err := c.options.combinedOutput(cmd)
 	if err != nil {
 		return string(out), errors.Wrap(err, "problem compiling test")
 	}
 
 	cmd = exec.Command(outputName)
 	grip.Infof("running test command: %s", strings.Join(cmd.Args, " "))
-	out, err = cmd.CombinedOutput()
+	out, err = c.options.combinedOutput(cmd)
 	if err != nil {
 		return string(out), errors.Wrap(err, "problem running test program")
 	}
@@ -64,8 +64,14 @@ func goCompilerAuto() compiler {
 }
 
 type compileGolang struct {
-	path string
-	bin  string
+	path    string
+	bin     string
+	options commandOptions
+}
+
+func (c compileGolang) withOptions(options commandOptions) compiler {
+	c.options = options
+	return c
 }
 
 func (c compileGolang) Validate() error {
@@ -87,6 +93,10 @@ func (c compileGolang) Compile(testBody string, _ ...string) error {
 	}
 	defer os.Remove(source)
 
+	if err = c.options.identity.chown(source); err != nil {
+		return err
+	}
+
 	cmd := exec.Command(c.bin, "build", source)
 	if c.path != "" {
 		cmd.Env = []string{c.path}
@@ -94,7 +104,7 @@ func (c compileGolang) Compile(testBody string, _ ...string) error {
 
 	grip.Infof("running build command: %s", cmd.Args)
 
-	out, err := cmd.CombinedOutput()
+	out, err := c.options.combinedOutput(cmd)
 	if err != nil {
 		return errors.Wrapf(err, "problem compiling go test: %s", string(out))
 	}
@@ -109,10 +119,14 @@ func (c compileGolang) CompileAndRun(testBody string, _ ...string) (string, erro
 	}
 	defer os.Remove(source)
 
+	if err = c.options.identity.chown(source); err != nil {
+		return "", err
+	}
+
 	cmd := exec.Command(c.bin, "run", source)
 	grip.Infof("running script: %s", cmd.Args)
 
-	out, err := cmd.CombinedOutput()
+	out, err := c.options.combinedOutput(cmd)
 	output := string(out)
 	if err != nil {
 		return output, errors.Wrapf(err, "problem running go program: %s", output)
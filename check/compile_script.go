@@ -33,7 +33,8 @@ func scriptCompilerInterfaceFactoryTable() map[string]compilerFactory {
 }
 
 type compileScript struct {
-	bin string
+	bin     string
+	options commandOptions
 }
 
 func pythonCompilerAuto() compiler {
@@ -59,6 +60,11 @@ func pythonCompilerAuto() compiler {
 	return c
 }
 
+func (c compileScript) withOptions(options commandOptions) compiler {
+	c.options = options
+	return c
+}
+
 func (c compileScript) Validate() error {
 	if c.bin == "" {
 		return errors.New("no script interpreter")
@@ -75,10 +81,14 @@ func (c compileScript) Compile(testBody string, _ ...string) error {
 
 	defer os.Remove(sourceName)
 
+	if err = c.options.identity.chown(sourceName); err != nil {
+		return err
+	}
+
 	cmd := exec.Command(c.bin, sourceName)
 	grip.Infof("running script script with command: %s", strings.Join(cmd.Args, " "))
 
-	output, err := cmd.CombinedOutput()
+	output, err := c.options.combinedOutput(cmd)
 	if err != nil {
 		return errors.Wrapf(err, "problem build/running test script %s: %s", sourceName,
 			string(output))
@@ -95,9 +105,13 @@ func (c compileScript) CompileAndRun(testBody string, _ ...string) (string, erro
 
 	defer os.Remove(sourceName)
 
+	if err = c.options.identity.chown(sourceName); err != nil {
+		return "", err
+	}
+
 	cmd := exec.Command(c.bin, sourceName)
 	grip.Infof("running script script with command: %s", strings.Join(cmd.Args, " "))
-	out, err := cmd.CombinedOutput()
+	out, err := c.options.combinedOutput(cmd)
 	output := string(out)
 	if err != nil {
 		return output, errors.Wrapf(err, "problem running test script %s", sourceName)
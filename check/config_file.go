@@ -0,0 +1,363 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "config-file-value"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &configFileValue{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// configValueAssertion describes an expected value in a structured
+// configuration file. Path is a dot separated list of keys (and
+// array indexes), for INI files the first element is the section
+// name. Operator is one of: eq, ne, matches, gt, gte, lt, lte,
+// exists, or absent; the symbolic forms ==, !=, =~, >, >=, <, and <=
+// are also accepted.
+type configValueAssertion struct {
+	Path     string `bson:"path" json:"path" yaml:"path"`
+	Operator string `bson:"operator" json:"operator" yaml:"operator"`
+	Value    string `bson:"value" json:"value" yaml:"value"`
+}
+
+var configValueOperatorAliases = map[string]string{
+	"":        "eq",
+	"eq":      "eq",
+	"==":      "eq",
+	"ne":      "ne",
+	"!=":      "ne",
+	"matches": "matches",
+	"=~":      "matches",
+	"gt":      "gt",
+	">":       "gt",
+	"gte":     "gte",
+	">=":      "gte",
+	"lt":      "lt",
+	"<":       "lt",
+	"lte":     "lte",
+	"<=":      "lte",
+	"exists":  "exists",
+	"absent":  "absent",
+}
+
+func (a *configValueAssertion) validate() error {
+	if a.Path == "" {
+		return errors.New("config value assertion does not specify a path")
+	}
+
+	op, ok := configValueOperatorAliases[a.Operator]
+	if !ok {
+		return errors.Errorf("operator '%s' for path '%s' is not valid", a.Operator, a.Path)
+	}
+	a.Operator = op
+
+	switch a.Operator {
+	case "matches":
+		if _, err := regexp.Compile(a.Value); err != nil {
+			return errors.Wrapf(err, "problem compiling pattern for path '%s'", a.Path)
+		}
+	case "gt", "gte", "lt", "lte":
+		if _, err := strconv.ParseFloat(a.Value, 64); err != nil {
+			return errors.Errorf("value '%s' for path '%s' is not a number", a.Value, a.Path)
+		}
+	}
+
+	return nil
+}
+
+// check returns true if the actual value satisfies the assertion,
+// and returns an error if the value cannot be compared.
+func (a *configValueAssertion) check(actual interface{}, exists bool) (bool, error) {
+	switch a.Operator {
+	case "exists":
+		return exists, nil
+	case "absent":
+		return !exists, nil
+	}
+
+	if !exists {
+		return false, nil
+	}
+
+	value := formatConfigValue(actual)
+
+	switch a.Operator {
+	case "eq", "ne":
+		equal := value == a.Value
+		if !equal {
+			actualNum, aErr := strconv.ParseFloat(value, 64)
+			expectedNum, eErr := strconv.ParseFloat(a.Value, 64)
+			equal = aErr == nil && eErr == nil && actualNum == expectedNum
+		}
+
+		return equal == (a.Operator == "eq"), nil
+	case "matches":
+		return regexp.MustCompile(a.Value).MatchString(value), nil
+	default:
+		actualNum, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false, errors.Errorf("value '%s' at path '%s' is not a number", value, a.Path)
+		}
+
+		expected, _ := strconv.ParseFloat(a.Value, 64)
+
+		switch a.Operator {
+		case "gt":
+			return actualNum > expected, nil
+		case "gte":
+			return actualNum >= expected, nil
+		case "lt":
+			return actualNum < expected, nil
+		default:
+			return actualNum <= expected, nil
+		}
+	}
+}
+
+// formatConfigValue renders scalar values the way they appear in
+// configuration files, and renders compound values as JSON.
+func formatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%+v", v)
+		}
+		return string(out)
+	}
+}
+
+// lookupConfigValue resolves a dot separated path in a document
+// produced by decoding JSON.
+func lookupConfigValue(doc interface{}, path string) (interface{}, bool) {
+	current := doc
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// parseINI converts INI-style data into a document with one map per
+// section. Keys that appear before the first section are stored at
+// the top level of the document.
+func parseINI(data []byte) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	section := doc
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, errors.Errorf("malformed section header on line %d", lineNum)
+			}
+
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			existing, ok := doc[name].(map[string]interface{})
+			if !ok {
+				existing = map[string]interface{}{}
+				doc[name] = existing
+			}
+			section = existing
+			continue
+		}
+
+		idx := strings.IndexAny(line, "=:")
+		if idx < 0 {
+			// treat bare keys as flags that are set
+			section[line] = ""
+			continue
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := strings.Trim(strings.TrimSpace(line[idx+1:]), `"'`)
+		section[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "problem reading ini data")
+	}
+
+	return doc, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Implementation of the Config File Value Check
+//
+////////////////////////////////////////////////////////////////////////
+
+type configFileValue struct {
+	FileName   string                  `bson:"name" json:"name" yaml:"name"`
+	Format     string                  `bson:"format" json:"format" yaml:"format"`
+	Assertions []*configValueAssertion `bson:"assertions" json:"assertions" yaml:"assertions"`
+	*Base      `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *configFileValue) validate() error {
+	if c.FileName == "" {
+		return errors.Errorf("no file specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Format == "" {
+		switch filepath.Ext(c.FileName) {
+		case ".json":
+			c.Format = "json"
+		case ".yaml", ".yml":
+			c.Format = "yaml"
+		case ".ini", ".cfg", ".conf", ".cnf":
+			c.Format = "ini"
+		default:
+			return errors.Errorf("cannot determine format of '%s', specify a format", c.FileName)
+		}
+		grip.Debugf("no format specified for '%s', using %s", c.FileName, c.Format)
+	}
+
+	switch c.Format {
+	case "json", "yaml", "ini":
+	default:
+		return errors.Errorf("config file format '%s' is not supported", c.Format)
+	}
+
+	if len(c.Assertions) == 0 {
+		return errors.Errorf("no assertions specified for file '%s'", c.FileName)
+	}
+
+	catcher := grip.NewCatcher()
+	for _, a := range c.Assertions {
+		catcher.Add(a.validate())
+	}
+
+	return catcher.Resolve()
+}
+
+func (c *configFileValue) parse() (interface{}, error) {
+	data, err := ioutil.ReadFile(c.FileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading file '%s'", c.FileName)
+	}
+
+	switch c.Format {
+	case "ini":
+		return parseINI(data)
+	case "yaml":
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem parsing yaml file '%s'", c.FileName)
+		}
+	}
+
+	var doc interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrapf(err, "problem parsing file '%s'", c.FileName)
+	}
+
+	return doc, nil
+}
+
+func (c *configFileValue) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	doc, err := c.parse()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var problems []string
+	for _, a := range c.Assertions {
+		actual, exists := lookupConfigValue(doc, a.Path)
+
+		result, err := a.check(actual, exists)
+		if err != nil {
+			c.AddError(err)
+		}
+
+		if result {
+			continue
+		}
+
+		if a.Operator == "exists" {
+			problems = append(problems, fmt.Sprintf("%s does not exist", a.Path))
+		} else if exists {
+			problems = append(problems, fmt.Sprintf("%s %s '%s' is false, actual value is '%s'",
+				a.Path, a.Operator, a.Value, formatConfigValue(actual)))
+		} else {
+			problems = append(problems, fmt.Sprintf("%s %s '%s' is false, path does not exist",
+				a.Path, a.Operator, a.Value))
+		}
+	}
+
+	grip.Debugf("config file check '%s' found %d failed assertions in '%s'",
+		c.ID(), len(problems), c.FileName)
+
+	if len(problems) > 0 {
+		c.setState(false)
+		c.setMessage(problems)
+		c.AddError(errors.Errorf("%d of %d assertions about '%s' failed",
+			len(problems), len(c.Assertions), c.FileName))
+		return
+	}
+
+	c.setState(true)
+}
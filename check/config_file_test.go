@@ -0,0 +1,143 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestINIParser(t *testing.T) {
+	assert := assert.New(t)
+
+	doc, err := parseINI([]byte("top = level\n; comment\n[main]\nkey = \"value\"\nflag\n\n[other]\nport: 80\n"))
+	assert.NoError(err)
+
+	value, ok := lookupConfigValue(doc, "top")
+	assert.True(ok)
+	assert.Equal("level", value)
+
+	value, ok = lookupConfigValue(doc, "main.key")
+	assert.True(ok)
+	assert.Equal("value", value)
+
+	_, ok = lookupConfigValue(doc, "main.flag")
+	assert.True(ok)
+
+	value, ok = lookupConfigValue(doc, "other.port")
+	assert.True(ok)
+	assert.Equal("80", value)
+
+	_, ok = lookupConfigValue(doc, "other.missing")
+	assert.False(ok)
+
+	_, err = parseINI([]byte("[broken\n"))
+	assert.Error(err)
+}
+
+func TestConfigValueLookup(t *testing.T) {
+	assert := assert.New(t)
+	doc := map[string]interface{}{
+		"net": map[string]interface{}{
+			"port":    float64(27017),
+			"members": []interface{}{"a", "b"},
+		},
+	}
+
+	value, ok := lookupConfigValue(doc, "net.port")
+	assert.True(ok)
+	assert.Equal("27017", formatConfigValue(value))
+
+	value, ok = lookupConfigValue(doc, "net.members.1")
+	assert.True(ok)
+	assert.Equal("b", value)
+
+	for _, path := range []string{"net.members.2", "net.members.x", "net.port.foo", "storage"} {
+		_, ok = lookupConfigValue(doc, path)
+		assert.False(ok, path)
+	}
+}
+
+func TestConfigFileValueCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-config-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "mongod.yaml")
+	require.NoError(ioutil.WriteFile(yamlFile,
+		[]byte("net:\n  port: 27017\n  bindIp: 127.0.0.1\nstorage:\n  journal:\n    enabled: true\n"), 0644))
+	jsonFile := filepath.Join(dir, "config.json")
+	require.NoError(ioutil.WriteFile(jsonFile, []byte(`{"limits": {"conns": 512}}`), 0644))
+	iniFile := filepath.Join(dir, "my.cnf")
+	require.NoError(ioutil.WriteFile(iniFile, []byte("[mysqld]\nmax_connections = 100\n"), 0644))
+
+	cases := []struct {
+		check  *configFileValue
+		passes bool
+	}{
+		{&configFileValue{FileName: yamlFile}, false},
+		{&configFileValue{FileName: filepath.Join(dir, "foo.txt"),
+			Assertions: []*configValueAssertion{{Path: "a", Operator: "exists"}}}, false},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "net.port", Operator: "bad"}}}, false},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "net.port", Value: "27017"}}}, true},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "net.port", Operator: "==", Value: "27017.0"}}}, true},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "net.port", Operator: "ne", Value: "27017"}}}, false},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "net.bindIp", Operator: "matches", Value: `^127\.`}}}, true},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "storage.journal.enabled", Value: "true"}}}, true},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "security", Operator: "exists"}}}, false},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "security", Operator: "absent"}}}, true},
+		{&configFileValue{FileName: yamlFile,
+			Assertions: []*configValueAssertion{{Path: "net.bindIp", Operator: "gt", Value: "1"}}}, false},
+		{&configFileValue{FileName: jsonFile,
+			Assertions: []*configValueAssertion{{Path: "limits.conns", Operator: ">=", Value: "500"}}}, true},
+		{&configFileValue{FileName: jsonFile,
+			Assertions: []*configValueAssertion{{Path: "limits.conns", Operator: "lt", Value: "500"}}}, false},
+		{&configFileValue{FileName: iniFile,
+			Assertions: []*configValueAssertion{{Path: "mysqld.max_connections", Operator: "lte", Value: "100"}}}, true},
+		{&configFileValue{FileName: iniFile, Format: "yaml",
+			Assertions: []*configValueAssertion{{Path: "mysqld", Operator: "exists"}}}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Base = NewBase("config-file-value", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+
+	// the message should include the actual value at the path
+	check := &configFileValue{
+		FileName: yamlFile,
+		Assertions: []*configValueAssertion{
+			{Path: "net.port", Value: "27018"},
+			{Path: "net.bindIp", Value: "0.0.0.0"},
+		},
+		Base: NewBase("config-file-value", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "net.port eq '27018' is false, actual value is '27017'")
+	assert.Contains(output.Message, "actual value is '127.0.0.1'")
+}
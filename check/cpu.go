@@ -0,0 +1,306 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "cpu"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &cpuCheck{
+			Base:         NewBase(name, 0),
+			procRoot:     "/proc",
+			sysRoot:      "/sys",
+			architecture: machineArchitecture,
+		}
+	})
+}
+
+// cpuCheck checks the processors of the system, using /proc/cpuinfo
+// and /sys/devices/system/cpu, and does not require root. Flags are
+// the instruction set extensions that the processors must support
+// (e.g. "sse4_2" or "avx"), from the "flags" (or "Features") of
+// /proc/cpuinfo. MinLogicalCores is the minimum number of online
+// logical processors, and MinPhysicalCores is the minimum number of
+// physical cores, which do not count hyperthreads. Architecture is the
+// machine's architecture, as either the kernel (e.g. "x86_64") or Go
+// (e.g. "amd64") name it, and ModelName is a regular expression that
+// matches the processor's model name. This check is only available on
+// linux.
+type cpuCheck struct {
+	Flags            []string `bson:"flags" json:"flags" yaml:"flags"`
+	MinLogicalCores  int      `bson:"min_logical_cores" json:"min_logical_cores" yaml:"min_logical_cores"`
+	MinPhysicalCores int      `bson:"min_physical_cores" json:"min_physical_cores" yaml:"min_physical_cores"`
+	Architecture     string   `bson:"architecture" json:"architecture" yaml:"architecture"`
+	ModelName        string   `bson:"model_name" json:"model_name" yaml:"model_name"`
+	*Base            `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	procRoot     string
+	sysRoot      string
+	architecture func() (string, error)
+}
+
+// cpuInfo describes the processors of the system.
+type cpuInfo struct {
+	model         string
+	flags         map[string]bool
+	logicalCores  int
+	physicalCores int
+}
+
+// cpuCore identifies a physical core, by package and core id.
+type cpuCore struct {
+	pkg  string
+	core string
+}
+
+// parseCPUInfo parses /proc/cpuinfo, which has a block of "key : value"
+// lines for each logical processor. The model name comes from the
+// first processor, and the flags are those that every processor
+// reports, so that a flag that only some processors support counts as
+// missing. Physical cores are counted using the "physical id" and
+// "core id" keys, which only some platforms report.
+func parseCPUInfo(fn string) (*cpuInfo, map[cpuCore]bool, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "problem opening '%s'", fn)
+	}
+	defer f.Close()
+
+	out := &cpuInfo{}
+	cores := map[cpuCore]bool{}
+	current := cpuCore{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		switch key {
+		case "processor":
+			if _, err := strconv.Atoi(value); err == nil {
+				out.logicalCores++
+			}
+			current = cpuCore{}
+		case "model name", "cpu model", "Model":
+			if out.model == "" {
+				out.model = value
+			}
+		case "flags", "Features":
+			flags := map[string]bool{}
+			for _, flag := range strings.Fields(value) {
+				if out.flags == nil || out.flags[flag] {
+					flags[flag] = true
+				}
+			}
+			out.flags = flags
+		case "physical id":
+			current.pkg = value
+		case "core id":
+			current.core = value
+			cores[current] = true
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, nil, errors.Wrapf(err, "problem reading '%s'", fn)
+	}
+
+	return out, cores, nil
+}
+
+// parseCPUList parses a list of processors in the format of
+// /sys/devices/system/cpu/online, such as "0-3,8-11".
+func parseCPUList(list string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, errors.Errorf("'%s' is not a valid cpu list", list)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil, errors.Errorf("'%s' is not a valid cpu list", list)
+			}
+		}
+
+		for cpu := first; cpu <= last; cpu++ {
+			out = append(out, cpu)
+		}
+	}
+
+	return out, nil
+}
+
+// readCPUTopology counts the online logical processors and physical
+// cores from /sys/devices/system/cpu, and reports false if the
+// topology is not available.
+func readCPUTopology(sysRoot string) (logical int, physical int, ok bool) {
+	dir := filepath.Join(sysRoot, "devices", "system", "cpu")
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "online"))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	cpus, err := parseCPUList(string(data))
+	if err != nil || len(cpus) == 0 {
+		return 0, 0, false
+	}
+
+	cores := map[cpuCore]bool{}
+	for _, cpu := range cpus {
+		topology := filepath.Join(dir, fmt.Sprintf("cpu%d", cpu), "topology")
+		pkg, err := ioutil.ReadFile(filepath.Join(topology, "physical_package_id"))
+		if err != nil {
+			return 0, 0, false
+		}
+
+		core, err := ioutil.ReadFile(filepath.Join(topology, "core_id"))
+		if err != nil {
+			return 0, 0, false
+		}
+
+		cores[cpuCore{pkg: strings.TrimSpace(string(pkg)), core: strings.TrimSpace(string(core))}] = true
+	}
+
+	return len(cpus), len(cores), true
+}
+
+// readCPUInfo combines /proc/cpuinfo with the topology in sysfs,
+// which is more reliable. Without either source of topology, every
+// logical processor counts as a physical core.
+func readCPUInfo(procRoot, sysRoot string) (*cpuInfo, error) {
+	info, cores, err := parseCPUInfo(filepath.Join(procRoot, "cpuinfo"))
+	if err != nil {
+		return nil, err
+	}
+
+	if logical, physical, ok := readCPUTopology(sysRoot); ok {
+		info.logicalCores = logical
+		info.physicalCores = physical
+	} else if len(cores) > 0 {
+		info.physicalCores = len(cores)
+	} else {
+		info.physicalCores = info.logicalCores
+	}
+
+	return info, nil
+}
+
+func (c *cpuCheck) validate() error {
+	catcher := grip.NewCatcher()
+
+	if len(c.Flags) == 0 && c.MinLogicalCores == 0 && c.MinPhysicalCores == 0 &&
+		c.Architecture == "" && c.ModelName == "" {
+		catcher.Add(errors.New("no cpu assertions specified"))
+	}
+
+	if c.MinLogicalCores < 0 || c.MinPhysicalCores < 0 {
+		catcher.Add(errors.Errorf("core counts cannot be negative [logical=%d, physical=%d]",
+			c.MinLogicalCores, c.MinPhysicalCores))
+	}
+
+	if c.ModelName != "" {
+		if _, err := regexp.Compile(c.ModelName); err != nil {
+			catcher.Add(errors.Wrapf(err, "problem compiling model name pattern '%s'", c.ModelName))
+		}
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "invalid '%s' (%s) check", c.ID(), c.Name())
+}
+
+func (c *cpuCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	info, err := readCPUInfo(c.procRoot, c.sysRoot)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	arch, err := c.architecture()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("cpu: '%s' (%s), %d logical cores, %d physical cores",
+		info.model, arch, info.logicalCores, info.physicalCores))
+
+	catcher := grip.NewCatcher()
+
+	var missing []string
+	for _, flag := range c.Flags {
+		if !info.flags[flag] {
+			missing = append(missing, flag)
+		}
+	}
+
+	if len(missing) > 0 {
+		catcher.Add(errors.Errorf("cpu does not support required flags: %s", strings.Join(missing, ", ")))
+	}
+
+	if info.logicalCores < c.MinLogicalCores {
+		catcher.Add(errors.Errorf("system has %d logical cores, fewer than the minimum of %d",
+			info.logicalCores, c.MinLogicalCores))
+	}
+
+	if info.physicalCores < c.MinPhysicalCores {
+		catcher.Add(errors.Errorf("system has %d physical cores, fewer than the minimum of %d",
+			info.physicalCores, c.MinPhysicalCores))
+	}
+
+	if c.Architecture != "" && c.Architecture != arch && c.Architecture != runtime.GOARCH {
+		catcher.Add(errors.Errorf("architecture is %s (%s), expected %s", arch, runtime.GOARCH, c.Architecture))
+	}
+
+	if c.ModelName != "" && !regexp.MustCompile(c.ModelName).MatchString(info.model) {
+		catcher.Add(errors.Errorf("cpu model '%s' does not match '%s'", info.model, c.ModelName))
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
@@ -0,0 +1,26 @@
+package check
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// machineArchitecture returns the machine's architecture, as uname(2)
+// reports it (e.g. "x86_64").
+func machineArchitecture() (string, error) {
+	uts := &syscall.Utsname{}
+	if err := syscall.Uname(uts); err != nil {
+		return "", errors.Wrap(err, "problem finding machine architecture")
+	}
+
+	var out []byte
+	for _, c := range uts.Machine {
+		if c == 0 {
+			break
+		}
+		out = append(out, byte(c))
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,11 @@
+// +build !linux
+
+package check
+
+import "runtime"
+
+// machineArchitecture returns the architecture that greenbay was
+// built for, on platforms where the cpu check is not available.
+func machineArchitecture() (string, error) {
+	return runtime.GOARCH, nil
+}
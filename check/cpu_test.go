@@ -0,0 +1,199 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCPUInfo writes a /proc/cpuinfo for an x86 system with two
+// packages of two cores, each with two hyperthreads.
+func writeTestCPUInfo(t *testing.T, procRoot string) {
+	var lines []string
+	for cpu := 0; cpu < 8; cpu++ {
+		lines = append(lines,
+			fmt.Sprintf("processor\t: %d", cpu),
+			"model name\t: Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz",
+			fmt.Sprintf("physical id\t: %d", cpu/4),
+			fmt.Sprintf("core id\t\t: %d", (cpu/2)%2),
+			"flags\t\t: fpu vme sse sse2 sse4_1 sse4_2 aes avx",
+			"")
+	}
+
+	require.NoError(t, os.MkdirAll(procRoot, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procRoot, "cpuinfo"), []byte(strings.Join(lines, "\n")), 0644))
+}
+
+// writeTestCPUTopology writes the topology of the online processors
+// to a fake /sys tree, with the package and core of each processor.
+func writeTestCPUTopology(t *testing.T, sysRoot, online string, cores [][2]int) {
+	dir := filepath.Join(sysRoot, "devices", "system", "cpu")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "online"), []byte(online+"\n"), 0644))
+
+	for cpu, core := range cores {
+		topology := filepath.Join(dir, fmt.Sprintf("cpu%d", cpu), "topology")
+		require.NoError(t, os.MkdirAll(topology, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(topology, "physical_package_id"),
+			[]byte(fmt.Sprintf("%d\n", core[0])), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(topology, "core_id"),
+			[]byte(fmt.Sprintf("%d\n", core[1])), 0644))
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	assert := assert.New(t)
+
+	cpus, err := parseCPUList("0\n")
+	assert.NoError(err)
+	assert.Equal([]int{0}, cpus)
+
+	cpus, err = parseCPUList("0-3,8-9,12")
+	assert.NoError(err)
+	assert.Equal([]int{0, 1, 2, 3, 8, 9, 12}, cpus)
+
+	for _, list := range []string{"a", "3-1", "0-b"} {
+		_, err = parseCPUList(list)
+		assert.Error(err, list)
+	}
+}
+
+func TestReadCPUInfo(t *testing.T) {
+	assert := assert.New(t)
+	root, err := ioutil.TempDir("", "greenbay-cpu")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	procRoot := filepath.Join(root, "proc")
+	writeTestCPUInfo(t, procRoot)
+
+	// without sysfs, the topology comes from cpuinfo.
+	info, err := readCPUInfo(procRoot, filepath.Join(root, "missing"))
+	require.NoError(t, err)
+	assert.Equal("Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz", info.model)
+	assert.Equal(8, info.logicalCores)
+	assert.Equal(4, info.physicalCores)
+	assert.True(info.flags["sse4_2"])
+	assert.False(info.flags["avx512f"])
+
+	// with sysfs, only online processors count.
+	sysRoot := filepath.Join(root, "sys")
+	writeTestCPUTopology(t, sysRoot, "0-2", [][2]int{{0, 0}, {0, 0}, {0, 1}})
+	info, err = readCPUInfo(procRoot, sysRoot)
+	require.NoError(t, err)
+	assert.Equal(3, info.logicalCores)
+	assert.Equal(2, info.physicalCores)
+
+	// processors without topology count as physical cores.
+	arm := filepath.Join(root, "arm")
+	require.NoError(t, os.MkdirAll(arm, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(arm, "cpuinfo"), []byte(
+		"processor\t: 0\nFeatures\t: fp asimd aes crc32\n\nprocessor\t: 1\nFeatures\t: fp asimd aes crc32\n"), 0644))
+	info, err = readCPUInfo(arm, filepath.Join(root, "missing"))
+	require.NoError(t, err)
+	assert.Equal(2, info.logicalCores)
+	assert.Equal(2, info.physicalCores)
+	assert.True(info.flags["aes"])
+
+	// flags that only some processors report are missing.
+	mixed := filepath.Join(root, "mixed")
+	require.NoError(t, os.MkdirAll(mixed, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(mixed, "cpuinfo"), []byte(
+		"processor\t: 0\nflags\t\t: fpu sse4_2 avx avx2\n\n"+
+			"processor\t: 1\nflags\t\t: fpu sse4_2 avx\n\n"+
+			"processor\t: 2\nflags\t\t: fpu sse4_2 avx avx2\n"), 0644))
+	info, err = readCPUInfo(mixed, filepath.Join(root, "missing"))
+	require.NoError(t, err)
+	assert.Equal(map[string]bool{"fpu": true, "sse4_2": true, "avx": true}, info.flags)
+
+	_, err = readCPUInfo(filepath.Join(root, "missing"), sysRoot)
+	assert.Error(err)
+}
+
+func TestCPUCheck(t *testing.T) {
+	root, err := ioutil.TempDir("", "greenbay-cpu")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	procRoot := filepath.Join(root, "proc")
+	writeTestCPUInfo(t, procRoot)
+
+	factory, err := registry.GetJobFactory("cpu")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		check   cpuCheck
+		passes  bool
+		message string
+	}{
+		"RequiredFlags":        {cpuCheck{Flags: []string{"sse4_2", "avx", "aes"}}, true, ""},
+		"MissingFlags":         {cpuCheck{Flags: []string{"sse4_2", "avx2", "avx512f"}}, false, "required flags: avx2, avx512f"},
+		"EnoughCores":          {cpuCheck{MinLogicalCores: 8, MinPhysicalCores: 4}, true, ""},
+		"NotEnoughLogical":     {cpuCheck{MinLogicalCores: 16}, false, "8 logical cores, fewer than the minimum of 16"},
+		"NotEnoughPhysical":    {cpuCheck{MinPhysicalCores: 8}, false, "4 physical cores, fewer than the minimum of 8"},
+		"KernelArchitecture":   {cpuCheck{Architecture: "x86_64"}, true, ""},
+		"GoArchitecture":       {cpuCheck{Architecture: runtime.GOARCH}, true, ""},
+		"WrongArchitecture":    {cpuCheck{Architecture: "s390x"}, false, "expected s390x"},
+		"ModelName":            {cpuCheck{ModelName: "Xeon.*v4"}, true, ""},
+		"WrongModelName":       {cpuCheck{ModelName: "^AMD"}, false, "does not match '^AMD'"},
+		"NoAssertions":         {cpuCheck{}, false, "no cpu assertions"},
+		"NegativeCores":        {cpuCheck{MinLogicalCores: -1}, false, "cannot be negative"},
+		"InvalidModelPattern":  {cpuCheck{ModelName: "(["}, false, "problem compiling"},
+		"MissingCPUInfoFailed": {cpuCheck{Flags: []string{"sse"}, procRoot: filepath.Join(root, "missing")}, false, "problem opening"},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*cpuCheck)
+			base := check.Base
+			*check = test.check
+			check.Base = base
+			if check.procRoot == "" {
+				check.procRoot = procRoot
+			}
+			check.sysRoot = filepath.Join(root, "missing")
+			check.architecture = func() (string, error) { return "x86_64", nil }
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if test.passes {
+				assert.Equal(t, "cpu: 'Intel(R) Xeon(R) CPU E5-2680 v4 @ 2.40GHz' (x86_64), "+
+					"8 logical cores, 4 physical cores", output.Message)
+			} else {
+				assert.Contains(t, output.Error, test.message)
+			}
+		})
+	}
+}
+
+func TestCPUCheckWithSystemProcessors(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("cpu checks are only available on linux")
+	}
+
+	check := &cpuCheck{
+		MinLogicalCores:  1,
+		MinPhysicalCores: 1,
+		Architecture:     runtime.GOARCH,
+		Base:             NewBase("cpu", 0),
+		procRoot:         "/proc",
+		sysRoot:          "/sys",
+		architecture:     machineArchitecture,
+	}
+	check.Run()
+
+	output := check.Output()
+	assert.True(t, output.Passed, output.Error)
+	assert.Contains(t, output.Message, "logical cores")
+}
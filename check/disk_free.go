@@ -0,0 +1,139 @@
+package check
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "disk-free"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &diskFreeCheck{
+			Base:      NewBase(name, 0),
+			diskUsage: getDiskUsage,
+		}
+	})
+}
+
+// diskFreeCheck checks the free space and inodes of the filesystem
+// that contains Path, using statfs. MinFree is the minimum free space,
+// in bytes with an optional K, M, or G suffix (e.g. "20G"), and
+// MinFreePercent is the minimum free space as a percentage of the
+// size of the filesystem. Free space only counts the space available
+// to unprivileged users. MinFreeInodes is the minimum number of free
+// inodes, and filesystems that allocate inodes dynamically, which
+// report no inodes, always satisfy it. The message reports the actual
+// values, whether or not the check passes.
+type diskFreeCheck struct {
+	Path           string  `bson:"path" json:"path" yaml:"path"`
+	MinFree        string  `bson:"min_free" json:"min_free" yaml:"min_free"`
+	MinFreePercent float64 `bson:"min_free_percent" json:"min_free_percent" yaml:"min_free_percent"`
+	MinFreeInodes  uint64  `bson:"min_free_inodes" json:"min_free_inodes" yaml:"min_free_inodes"`
+	*Base          `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	diskUsage func(string) (*diskUsage, error)
+}
+
+// diskUsage describes the size and free space of a filesystem.
+type diskUsage struct {
+	total      uint64
+	free       uint64
+	inodes     uint64
+	freeInodes uint64
+}
+
+func percentOf(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return 100 * float64(part) / float64(total)
+}
+
+func (u *diskUsage) String() string {
+	out := fmt.Sprintf("%d of %d bytes free (%.1f%%)", u.free, u.total, percentOf(u.free, u.total))
+	if u.inodes > 0 {
+		out += fmt.Sprintf(", %d of %d inodes free (%.1f%%)",
+			u.freeInodes, u.inodes, percentOf(u.freeInodes, u.inodes))
+	}
+
+	return out
+}
+
+func (c *diskFreeCheck) validate() error {
+	catcher := grip.NewCatcher()
+
+	if c.Path == "" {
+		catcher.Add(errors.New("no path specified"))
+	}
+
+	if c.MinFree == "" && c.MinFreePercent == 0 && c.MinFreeInodes == 0 {
+		catcher.Add(errors.Errorf("no free space or inode minimums specified for path '%s'", c.Path))
+	}
+
+	if c.MinFree != "" {
+		if _, err := parseByteSize(c.MinFree); err != nil {
+			catcher.Add(errors.Wrap(err, "invalid minimum free space"))
+		}
+	}
+
+	if c.MinFreePercent < 0 || c.MinFreePercent > 100 || math.IsNaN(c.MinFreePercent) {
+		catcher.Add(errors.Errorf("minimum free percentage %g is not between 0 and 100", c.MinFreePercent))
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "invalid '%s' (%s) check", c.ID(), c.Name())
+}
+
+func (c *diskFreeCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	usage, err := c.diskUsage(c.Path)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem finding free space for path '%s'", c.Path))
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("path '%s': %s", c.Path, usage))
+
+	catcher := grip.NewCatcher()
+
+	if c.MinFree != "" {
+		minimum, _ := parseByteSize(c.MinFree)
+		if usage.free < minimum {
+			catcher.Add(errors.Errorf("path '%s' has %d bytes free, fewer than the minimum of %s",
+				c.Path, usage.free, c.MinFree))
+		}
+	}
+
+	if percent := percentOf(usage.free, usage.total); percent < c.MinFreePercent {
+		catcher.Add(errors.Errorf("path '%s' has %.1f%% free, less than the minimum of %g%%",
+			c.Path, percent, c.MinFreePercent))
+	}
+
+	if usage.inodes > 0 && usage.freeInodes < c.MinFreeInodes {
+		catcher.Add(errors.Errorf("path '%s' has %d inodes free, fewer than the minimum of %d",
+			c.Path, usage.freeInodes, c.MinFreeInodes))
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
@@ -0,0 +1,11 @@
+// +build freebsd darwin
+
+package check
+
+import "syscall"
+
+// blockSize returns the unit of the block counts from statfs, which
+// on BSD systems is the fundamental block size, Bsize.
+func blockSize(stat *syscall.Statfs_t) uint64 {
+	return uint64(stat.Bsize)
+}
@@ -0,0 +1,81 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/greenbay"
+	"github.com/pkg/errors"
+)
+
+func registerDiskFreeGroupChecks() {
+	diskFreeGroupFactoryFactory := func(name string, gr GroupRequirements) func() amboy.Job {
+		gr.Name = name
+		return func() amboy.Job {
+			return &diskFreeGroup{
+				Base:         NewBase(name, 0),
+				Requirements: gr,
+				diskUsage:    getDiskUsage,
+			}
+		}
+	}
+
+	for group, requirements := range groupRequirementRegistry {
+		name := fmt.Sprintf("disk-free-group-%s", group)
+		registry.AddJobType(name, diskFreeGroupFactoryFactory(name, requirements))
+	}
+}
+
+// diskFreeGroup checks the free space of several paths, such as all
+// of the data volumes of a host, with the semantics of disk-free
+// checks. Unlike other groups, the message always reports the free
+// space of every path, rather than only the paths that explain a
+// failure.
+type diskFreeGroup struct {
+	Checks       []*diskFreeCheck  `bson:"checks" json:"checks" yaml:"checks"`
+	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	diskUsage func(string) (*diskUsage, error)
+}
+
+func (c *diskFreeGroup) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.Requirements.Validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if len(c.Checks) == 0 {
+		c.setState(false)
+		c.AddError(errors.Errorf("no disk free checks specified for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
+	checks := make([]greenbay.Checker, 0, len(c.Checks))
+	for idx, check := range c.Checks {
+		if check.Base == nil {
+			check.Base = NewBase(fmt.Sprintf("%s-%d", c.ID(), idx), c.Type().Version)
+		}
+		if check.diskUsage == nil {
+			check.diskUsage = c.diskUsage
+		}
+
+		checks = append(checks, check)
+	}
+
+	c.runGroupChecks(c.Requirements, checks)
+
+	var output []string
+	for _, check := range c.Checks {
+		if msg := check.Output().Message; msg != "" {
+			output = append(output, msg)
+		}
+	}
+	c.setMessage(output)
+}
@@ -0,0 +1,17 @@
+// +build linux
+
+package check
+
+import "syscall"
+
+// blockSize returns the unit of the block counts from statfs, which
+// on linux is the fragment size. Bsize is the preferred I/O size,
+// which may differ, and older kernels that do not report a fragment
+// size use the same value for both.
+func blockSize(stat *syscall.Statfs_t) uint64 {
+	if stat.Frsize > 0 {
+		return uint64(stat.Frsize)
+	}
+
+	return uint64(stat.Bsize)
+}
@@ -0,0 +1,15 @@
+package check
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockSizeUsesFragmentSize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(uint64(1024), blockSize(&syscall.Statfs_t{Bsize: 4096, Frsize: 1024}))
+	assert.Equal(uint64(4096), blockSize(&syscall.Statfs_t{Bsize: 4096}))
+}
@@ -0,0 +1,13 @@
+// +build !linux,!freebsd,!darwin
+
+package check
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func getDiskUsage(path string) (*diskUsage, error) {
+	return nil, errors.Errorf("disk free checks are not supported on this platform (%s)", runtime.GOOS)
+}
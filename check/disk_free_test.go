@@ -0,0 +1,192 @@
+package check
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDiskUsage returns a function that reports the usage of fake
+// filesystems by path.
+func testDiskUsage(filesystems map[string]*diskUsage) func(string) (*diskUsage, error) {
+	return func(path string) (*diskUsage, error) {
+		usage, ok := filesystems[path]
+		if !ok {
+			return nil, errors.Errorf("no such file or directory: '%s'", path)
+		}
+
+		return usage, nil
+	}
+}
+
+var testFilesystems = map[string]*diskUsage{
+	// 100G, with 25G and 500 of 1000 inodes free.
+	"/data": {total: 100 << 30, free: 25 << 30, inodes: 1000, freeInodes: 500},
+	// 10G, with 512M free.
+	"/data/journal": {total: 10 << 30, free: 512 << 20, inodes: 1000, freeInodes: 10},
+	// a filesystem without a fixed number of inodes.
+	"/btrfs": {total: 10 << 30, free: 5 << 30},
+}
+
+func TestDiskUsageString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("26843545600 of 107374182400 bytes free (25.0%), 500 of 1000 inodes free (50.0%)",
+		testFilesystems["/data"].String())
+	assert.Equal("5368709120 of 10737418240 bytes free (50.0%)", testFilesystems["/btrfs"].String())
+	assert.Equal("0 of 0 bytes free (0.0%)", (&diskUsage{}).String())
+}
+
+func TestDiskFreeCheck(t *testing.T) {
+	factory, err := registry.GetJobFactory("disk-free")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		check  diskFreeCheck
+		passes bool
+	}{
+		"EnoughFreeSpace":      {diskFreeCheck{Path: "/data", MinFree: "20G"}, true},
+		"NotEnoughFreeSpace":   {diskFreeCheck{Path: "/data/journal", MinFree: "1G"}, false},
+		"EnoughFreePercent":    {diskFreeCheck{Path: "/data", MinFreePercent: 20}, true},
+		"NotEnoughFreePercent": {diskFreeCheck{Path: "/data", MinFreePercent: 30}, false},
+		"EnoughFreeInodes":     {diskFreeCheck{Path: "/data", MinFreeInodes: 500}, true},
+		"NotEnoughFreeInodes":  {diskFreeCheck{Path: "/data/journal", MinFreeInodes: 100}, false},
+		"DynamicInodes":        {diskFreeCheck{Path: "/btrfs", MinFreeInodes: 100}, true},
+		"AllMinimums":          {diskFreeCheck{Path: "/data", MinFree: "1G", MinFreePercent: 10, MinFreeInodes: 1}, true},
+		"OneMinimumFails":      {diskFreeCheck{Path: "/data", MinFree: "1G", MinFreePercent: 50}, false},
+		"MissingPath":          {diskFreeCheck{Path: "/missing", MinFree: "1G"}, false},
+		"NoPath":               {diskFreeCheck{MinFree: "1G"}, false},
+		"NoMinimums":           {diskFreeCheck{Path: "/data"}, false},
+		"InvalidSize":          {diskFreeCheck{Path: "/data", MinFree: "lots"}, false},
+		"InvalidPercent":       {diskFreeCheck{Path: "/data", MinFreePercent: 101}, false},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*diskFreeCheck)
+			base := check.Base
+			*check = test.check
+			check.Base = base
+			check.diskUsage = testDiskUsage(testFilesystems)
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if usage, ok := testFilesystems[test.check.Path]; ok && check.validate() == nil {
+				assert.Equal(t, "path '"+test.check.Path+"': "+usage.String(), output.Message)
+			}
+		})
+	}
+}
+
+func TestDiskFreeCheckWithFilesystem(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("disk free checks are not supported on windows")
+	}
+
+	check := &diskFreeCheck{
+		Path:      os.TempDir(),
+		MinFree:   "1",
+		Base:      NewBase("disk-free", 0),
+		diskUsage: getDiskUsage,
+	}
+	check.Run()
+
+	output := check.Output()
+	assert.True(t, output.Passed, output.Error)
+	assert.Contains(t, output.Message, "bytes free")
+
+	usage, err := getDiskUsage(os.TempDir())
+	require.NoError(t, err)
+	assert.True(t, usage.total >= usage.free)
+	assert.True(t, usage.total > 0)
+}
+
+func TestDiskFreeGroup(t *testing.T) {
+	newGroup := func(t *testing.T, name string) *diskFreeGroup {
+		factory, err := registry.GetJobFactory(name)
+		require.NoError(t, err)
+		group, ok := factory().(*diskFreeGroup)
+		require.True(t, ok)
+		group.diskUsage = testDiskUsage(testFilesystems)
+		return group
+	}
+
+	t.Run("NoChecks", func(t *testing.T) {
+		group := newGroup(t, "disk-free-group-all")
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.False(t, output.Passed)
+		assert.Error(t, group.Error())
+	})
+
+	t.Run("AllPassing", func(t *testing.T) {
+		group := newGroup(t, "disk-free-group-all")
+		group.Checks = []*diskFreeCheck{
+			{Path: "/data", MinFreePercent: 10},
+			{Path: "/data/journal", MinFree: "256M"},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.True(t, output.Passed)
+		assert.NoError(t, group.Error())
+		assert.Contains(t, output.Message, "path '/data': "+testFilesystems["/data"].String())
+		assert.Contains(t, output.Message, "path '/data/journal': "+testFilesystems["/data/journal"].String())
+	})
+
+	t.Run("AllWithFailure", func(t *testing.T) {
+		group := newGroup(t, "disk-free-group-all")
+		group.Checks = []*diskFreeCheck{
+			{Path: "/data", MinFreePercent: 10},
+			{Path: "/data/journal", MinFreePercent: 10},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.False(t, output.Passed)
+		assert.Contains(t, output.Error, "path '/data/journal' has 5.0% free, less than the minimum of 10%")
+		assert.Contains(t, output.Message, "path '/data': ")
+	})
+
+	t.Run("NoneWithPassing", func(t *testing.T) {
+		group := newGroup(t, "disk-free-group-none")
+		group.Checks = []*diskFreeCheck{
+			{Path: "/data", MinFreePercent: 10},
+			{Path: "/data/journal", MinFreePercent: 10},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.False(t, output.Passed)
+		assert.Error(t, group.Error())
+		assert.Contains(t, output.Message, "path '/data': ")
+		assert.Contains(t, output.Message, "path '/data/journal': ")
+	})
+
+	t.Run("AnyWithFailure", func(t *testing.T) {
+		group := newGroup(t, "disk-free-group-any")
+		group.Checks = []*diskFreeCheck{
+			{Path: "/data", MinFreePercent: 10},
+			{Path: "/data/journal", MinFreePercent: 10},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.True(t, output.Passed)
+		assert.NoError(t, group.Error())
+	})
+}
@@ -0,0 +1,30 @@
+// +build linux freebsd darwin
+
+package check
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+func getDiskUsage(path string) (*diskUsage, error) {
+	stat := &syscall.Statfs_t{}
+	if err := syscall.Statfs(path, stat); err != nil {
+		return nil, errors.Wrapf(err, "problem running statfs on '%s'", path)
+	}
+
+	// on some platforms, the number of available blocks may be
+	// negative when the filesystem uses its reserved blocks.
+	available := int64(stat.Bavail)
+	if available < 0 {
+		available = 0
+	}
+
+	return &diskUsage{
+		total:      uint64(stat.Blocks) * blockSize(stat),
+		free:       uint64(available) * blockSize(stat),
+		inodes:     uint64(stat.Files),
+		freeInodes: uint64(stat.Ffree),
+	}, nil
+}
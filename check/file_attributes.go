@@ -0,0 +1,348 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "file-attributes"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &fileAttributes{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// fileAttributes asserts the mode, ownership, type, symlink target,
+// and size of a file. Owner and Group accept either names or numeric
+// ids, and Mode accepts either octal (e.g. "0644" or "4755") or
+// symbolic (e.g. "rw-r--r--") notation. When the expected type is
+// "symlink" or a LinkTarget is specified, the check inspects the link
+// itself rather than the file it points to. A MaxSize of 0 means that
+// there is no upper bound.
+type fileAttributes struct {
+	FileName   string `bson:"name" json:"name" yaml:"name"`
+	Mode       string `bson:"mode" json:"mode" yaml:"mode"`
+	Owner      string `bson:"owner" json:"owner" yaml:"owner"`
+	Group      string `bson:"group" json:"group" yaml:"group"`
+	FileType   string `bson:"type" json:"type" yaml:"type"`
+	LinkTarget string `bson:"link_target" json:"link_target" yaml:"link_target"`
+	MinSize    int64  `bson:"min_size" json:"min_size" yaml:"min_size"`
+	MaxSize    int64  `bson:"max_size" json:"max_size" yaml:"max_size"`
+	*Base      `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+var fileTypeAliases = map[string]string{
+	"file":             "regular",
+	"regular":          "regular",
+	"dir":              "directory",
+	"directory":        "directory",
+	"link":             "symlink",
+	"symlink":          "symlink",
+	"socket":           "socket",
+	"fifo":             "fifo",
+	"pipe":             "fifo",
+	"device":           "device",
+	"block-device":     "device",
+	"char-device":      "char-device",
+	"character-device": "char-device",
+}
+
+func fileTypeName(mode os.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return "regular"
+	case mode.IsDir():
+		return "directory"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&os.ModeCharDevice != 0:
+		return "char-device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	default:
+		return "unknown"
+	}
+}
+
+// unixModeBits converts the permission and special bits of an
+// os.FileMode into the traditional octal representation.
+func unixModeBits(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+
+	return bits
+}
+
+// parseFileMode converts octal or symbolic ("rwxr-xr-x", with an
+// optional leading file type character) mode specifications into the
+// traditional octal representation.
+func parseFileMode(spec string) (uint32, error) {
+	if spec == "" {
+		return 0, errors.New("mode specification is empty")
+	}
+
+	if bits, err := strconv.ParseUint(spec, 8, 32); err == nil {
+		if bits > 07777 {
+			return 0, errors.Errorf("mode '%s' is out of range", spec)
+		}
+		return uint32(bits), nil
+	}
+
+	if len(spec) == 10 {
+		spec = spec[1:]
+	}
+
+	if len(spec) != 9 {
+		return 0, errors.Errorf("mode '%s' is neither octal nor symbolic", spec)
+	}
+
+	var bits uint32
+	for idx, char := range spec {
+		shift := uint(8 - idx)
+		position := idx % 3
+		switch {
+		case char == '-':
+			continue
+		case position == 0 && char == 'r', position == 1 && char == 'w', position == 2 && char == 'x':
+			bits |= 1 << shift
+		case position == 2 && (char == 's' || char == 'S') && idx == 2:
+			bits |= 04000
+		case position == 2 && (char == 's' || char == 'S') && idx == 5:
+			bits |= 02000
+		case position == 2 && (char == 't' || char == 'T') && idx == 8:
+			bits |= 01000
+		default:
+			return 0, errors.Errorf("invalid character '%c' in mode '%s'", char, spec)
+		}
+
+		if char == 's' || char == 't' {
+			bits |= 1 << shift
+		}
+	}
+
+	return bits, nil
+}
+
+func resolveUserID(spec string) (uint32, error) {
+	if id, err := strconv.ParseUint(spec, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+
+	u, err := user.Lookup(spec)
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem looking up user '%s'", spec)
+	}
+
+	id, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "user '%s' has non-numeric uid '%s'", spec, u.Uid)
+	}
+
+	return uint32(id), nil
+}
+
+func resolveGroupID(spec string) (uint32, error) {
+	if id, err := strconv.ParseUint(spec, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+
+	g, err := user.LookupGroup(spec)
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem looking up group '%s'", spec)
+	}
+
+	id, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "group '%s' has non-numeric gid '%s'", spec, g.Gid)
+	}
+
+	return uint32(id), nil
+}
+
+func (c *fileAttributes) validate() error {
+	if c.FileName == "" {
+		return errors.Errorf("no file specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Mode == "" && c.Owner == "" && c.Group == "" && c.FileType == "" &&
+		c.LinkTarget == "" && c.MinSize == 0 && c.MaxSize == 0 {
+		return errors.Errorf("no attributes specified for file '%s'", c.FileName)
+	}
+
+	if c.FileType != "" {
+		normalized, ok := fileTypeAliases[strings.ToLower(c.FileType)]
+		if !ok {
+			return errors.Errorf("file type '%s' is not valid", c.FileType)
+		}
+		c.FileType = normalized
+	}
+
+	if c.LinkTarget != "" && c.FileType != "" && c.FileType != "symlink" {
+		return errors.Errorf("cannot check link target of '%s' with type '%s'",
+			c.FileName, c.FileType)
+	}
+
+	if c.MinSize < 0 || c.MaxSize < 0 {
+		return errors.Errorf("sizes for file '%s' cannot be negative [min=%d, max=%d]",
+			c.FileName, c.MinSize, c.MaxSize)
+	}
+
+	if c.MaxSize > 0 && c.MinSize > c.MaxSize {
+		return errors.Errorf("minimum size %d is larger than maximum %d for file '%s'",
+			c.MinSize, c.MaxSize, c.FileName)
+	}
+
+	return nil
+}
+
+// evaluate compares the file against every configured attribute and
+// returns a message for every attribute that does not match.
+func (c *fileAttributes) evaluate() ([]string, error) {
+	var stat os.FileInfo
+	var err error
+
+	if c.FileType == "symlink" || c.LinkTarget != "" {
+		stat, err = os.Lstat(c.FileName)
+	} else {
+		stat, err = os.Stat(c.FileName)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding file '%s'", c.FileName)
+	}
+
+	var problems []string
+
+	if c.FileType != "" {
+		if actual := fileTypeName(stat.Mode()); actual != c.FileType {
+			problems = append(problems, fmt.Sprintf("type is '%s' not '%s'", actual, c.FileType))
+		}
+	}
+
+	if c.Mode != "" {
+		expected, err := parseFileMode(c.Mode)
+		if err != nil {
+			return nil, err
+		}
+
+		if actual := unixModeBits(stat.Mode()); actual != expected {
+			problems = append(problems, fmt.Sprintf("mode is %04o (%s) not %04o",
+				actual, stat.Mode().Perm(), expected))
+		}
+	}
+
+	if c.Owner != "" || c.Group != "" {
+		uid, gid, err := fileOwnership(stat)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.Owner != "" {
+			expected, err := resolveUserID(c.Owner)
+			if err != nil {
+				return nil, err
+			}
+
+			if uid != expected {
+				problems = append(problems, fmt.Sprintf("owner uid is %d not %d (%s)",
+					uid, expected, c.Owner))
+			}
+		}
+
+		if c.Group != "" {
+			expected, err := resolveGroupID(c.Group)
+			if err != nil {
+				return nil, err
+			}
+
+			if gid != expected {
+				problems = append(problems, fmt.Sprintf("group gid is %d not %d (%s)",
+					gid, expected, c.Group))
+			}
+		}
+	}
+
+	if c.LinkTarget != "" {
+		if stat.Mode()&os.ModeSymlink == 0 {
+			problems = append(problems, "file is not a symlink so it has no link target")
+		} else {
+			target, err := os.Readlink(c.FileName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem reading link target of '%s'", c.FileName)
+			}
+
+			if target != c.LinkTarget {
+				problems = append(problems, fmt.Sprintf("link target is '%s' not '%s'",
+					target, c.LinkTarget))
+			}
+		}
+	}
+
+	if stat.Size() < c.MinSize {
+		problems = append(problems, fmt.Sprintf("size is %d bytes, smaller than the minimum of %d",
+			stat.Size(), c.MinSize))
+	}
+
+	if c.MaxSize > 0 && stat.Size() > c.MaxSize {
+		problems = append(problems, fmt.Sprintf("size is %d bytes, larger than the maximum of %d",
+			stat.Size(), c.MaxSize))
+	}
+
+	return problems, nil
+}
+
+func (c *fileAttributes) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	problems, err := c.evaluate()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	grip.Debugf("file attributes check '%s' found %d mismatches for '%s'",
+		c.ID(), len(problems), c.FileName)
+
+	if len(problems) > 0 {
+		c.setState(false)
+		c.setMessage(fmt.Sprintf("file '%s' has %d incorrect attribute(s): %s",
+			c.FileName, len(problems), strings.Join(problems, "; ")))
+		c.AddError(errors.Errorf("attributes of file '%s' do not satisfy check requirements",
+			c.FileName))
+		return
+	}
+
+	c.setState(true)
+}
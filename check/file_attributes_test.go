@@ -0,0 +1,149 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileModeParsing(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]uint32{
+		"0644":       0644,
+		"755":        0755,
+		"4755":       04755,
+		"rw-r--r--":  0644,
+		"-rwxr-x---": 0750,
+		"rwsr-xr-x":  04755,
+		"rwxr-Sr-x":  02745,
+		"rwxrwxrwt":  01777,
+	}
+
+	for spec, expected := range cases {
+		bits, err := parseFileMode(spec)
+		assert.NoError(err, spec)
+		assert.Equal(expected, bits, spec)
+	}
+
+	for _, spec := range []string{"", "99", "17777", "rwxrwxrw", "rwtrwxrwx", "abcdefghi"} {
+		_, err := parseFileMode(spec)
+		assert.Error(err, spec)
+	}
+}
+
+func TestFileAttributesValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &fileAttributes{Base: NewBase("file-attributes", 0)}
+	assert.Error(check.validate())
+
+	check.FileName = "foo"
+	assert.Error(check.validate())
+
+	check.FileType = "Dir"
+	assert.NoError(check.validate())
+	assert.Equal("directory", check.FileType)
+
+	check.LinkTarget = "bar"
+	assert.Error(check.validate())
+
+	check.FileType = "not-a-type"
+	assert.Error(check.validate())
+
+	check.FileType = ""
+	check.LinkTarget = ""
+	check.MinSize = 10
+	check.MaxSize = 5
+	assert.Error(check.validate())
+}
+
+func TestFileAttributesCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-attributes-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "file")
+	require.NoError(ioutil.WriteFile(fn, []byte("0123456789"), 0600))
+	require.NoError(os.Chmod(fn, 0640))
+
+	link := filepath.Join(dir, "link")
+	require.NoError(os.Symlink(fn, link))
+
+	cases := []struct {
+		check  *fileAttributes
+		passes bool
+	}{
+		{&fileAttributes{FileName: fn, Mode: "0640"}, true},
+		{&fileAttributes{FileName: fn, Mode: "rw-r-----"}, true},
+		{&fileAttributes{FileName: fn, Mode: "0644"}, false},
+		{&fileAttributes{FileName: fn, FileType: "regular"}, true},
+		{&fileAttributes{FileName: fn, FileType: "directory"}, false},
+		{&fileAttributes{FileName: dir, FileType: "dir"}, true},
+		{&fileAttributes{FileName: link, FileType: "symlink", LinkTarget: fn}, true},
+		{&fileAttributes{FileName: link, LinkTarget: "/etc/passwd"}, false},
+		{&fileAttributes{FileName: link, FileType: "regular", Mode: "0640"}, true},
+		{&fileAttributes{FileName: fn, LinkTarget: fn}, false},
+		{&fileAttributes{FileName: fn, MinSize: 5, MaxSize: 10}, true},
+		{&fileAttributes{FileName: fn, MinSize: 11}, false},
+		{&fileAttributes{FileName: fn, MaxSize: 9}, false},
+		{&fileAttributes{FileName: fn + ".DOES-NOT-EXIST", Mode: "0640"}, false},
+	}
+
+	if runtime.GOOS != "windows" {
+		uid := strconv.Itoa(os.Getuid())
+		gid := strconv.Itoa(os.Getgid())
+		cases = append(cases,
+			struct {
+				check  *fileAttributes
+				passes bool
+			}{&fileAttributes{FileName: fn, Owner: uid, Group: gid}, true},
+			struct {
+				check  *fileAttributes
+				passes bool
+			}{&fileAttributes{FileName: fn, Owner: uid + "1"}, false},
+			struct {
+				check  *fileAttributes
+				passes bool
+			}{&fileAttributes{FileName: fn, Owner: "greenbay-user-does-not-exist"}, false})
+	}
+
+	for idx, c := range cases {
+		c.check.Base = NewBase("file-attributes", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+
+	// all mismatched attributes should be reported together
+	check := &fileAttributes{
+		FileName: fn,
+		Mode:     "0755",
+		FileType: "directory",
+		MinSize:  100,
+		Base:     NewBase("file-attributes", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "3 incorrect attribute(s)")
+	assert.Contains(output.Message, "mode is 0640")
+	assert.Contains(output.Message, "type is 'regular'")
+	assert.Contains(output.Message, "size is 10 bytes")
+}
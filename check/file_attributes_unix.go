@@ -0,0 +1,19 @@
+// +build linux freebsd solaris darwin
+
+package check
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+func fileOwnership(stat os.FileInfo) (uint32, uint32, error) {
+	sys, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, errors.Errorf("could not determine ownership of '%s'", stat.Name())
+	}
+
+	return sys.Uid, sys.Gid, nil
+}
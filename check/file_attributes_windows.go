@@ -0,0 +1,15 @@
+// +build windows
+
+package check
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func fileOwnership(stat os.FileInfo) (uint32, uint32, error) {
+	return 0, 0, errors.Errorf("file ownership checks for '%s' are not supported on this platform (%s)",
+		stat.Name(), runtime.GOOS)
+}
@@ -0,0 +1,325 @@
+package check
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "file-checksum"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &fileChecksum{
+			Base: NewBase(name, 0),
+		}
+	})
+}
+
+func registerFileChecksumGroupChecks() {
+	fileChecksumGroupFactoryFactory := func(name string, gr GroupRequirements) func() amboy.Job {
+		gr.Name = name
+		return func() amboy.Job {
+			return &fileChecksumGroup{
+				Base:         NewBase(name, 0),
+				Requirements: gr,
+			}
+		}
+	}
+
+	for group, requirements := range groupRequirementRegistry {
+		name := fmt.Sprintf("file-checksum-group-%s", group)
+		registry.AddJobType(name, fileChecksumGroupFactoryFactory(name, requirements))
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Shared Checksum Specification and Evaluation
+//
+////////////////////////////////////////////////////////////////////////
+
+// checksumSpec describes a set of files and their expected
+// digests. Files maps file names to hex digests, and Manifest names a
+// file in the format produced by sha256sum (or sha1sum or md5sum),
+// relative paths in which are resolved relative to Root, or the
+// directory containing the manifest if Root is not set. When
+// Algorithm is empty, the algorithm is inferred from the length of
+// each digest.
+type checksumSpec struct {
+	Algorithm string            `bson:"algorithm" json:"algorithm" yaml:"algorithm"`
+	Files     map[string]string `bson:"files" json:"files" yaml:"files"`
+	Manifest  string            `bson:"manifest" json:"manifest" yaml:"manifest"`
+	Root      string            `bson:"root" json:"root" yaml:"root"`
+}
+
+type checksumResults struct {
+	matched    []string
+	mismatched []string
+	missing    []string
+}
+
+func (r *checksumResults) failures() int {
+	return len(r.mismatched) + len(r.missing)
+}
+
+func (r *checksumResults) messages() []string {
+	var out []string
+
+	if len(r.mismatched) > 0 {
+		out = append(out, fmt.Sprintf("%d file(s) with mismatched checksums:", len(r.mismatched)))
+		out = append(out, r.mismatched...)
+	}
+
+	if len(r.missing) > 0 {
+		out = append(out, fmt.Sprintf("%d missing file(s):", len(r.missing)))
+		out = append(out, r.missing...)
+	}
+
+	return out
+}
+
+func newChecksumHash(algorithm, digest string) (hash.Hash, error) {
+	if algorithm == "" {
+		switch len(digest) {
+		case md5.Size * 2:
+			algorithm = "md5"
+		case sha1.Size * 2:
+			algorithm = "sha1"
+		case sha256.Size * 2:
+			algorithm = "sha256"
+		default:
+			return nil, errors.Errorf("cannot infer algorithm for digest '%s'", digest)
+		}
+	}
+
+	switch algorithm {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	default:
+		return nil, errors.Errorf("checksum algorithm '%s' is not supported", algorithm)
+	}
+}
+
+func (s *checksumSpec) validate() error {
+	switch s.Algorithm {
+	case "", "md5", "sha1", "sha256":
+	default:
+		return errors.Errorf("checksum algorithm '%s' is not supported", s.Algorithm)
+	}
+
+	if len(s.Files) == 0 && s.Manifest == "" {
+		return errors.New("no files or manifest specified for checksum check")
+	}
+
+	return nil
+}
+
+// readManifest parses a sha256sum-style manifest, where each line is
+// a hex digest followed by whitespace and a file name, optionally
+// prefixed with '*' to indicate binary mode.
+func (s *checksumSpec) readManifest() (map[string]string, error) {
+	f, err := os.Open(s.Manifest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening manifest '%s'", s.Manifest)
+	}
+	defer f.Close()
+
+	root := s.Root
+	if root == "" {
+		root = filepath.Dir(s.Manifest)
+	}
+
+	out := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("malformed line %d in manifest '%s'", lineNum, s.Manifest)
+		}
+
+		fn := strings.TrimPrefix(strings.TrimLeft(parts[1], " "), "*")
+		if !filepath.IsAbs(fn) {
+			fn = filepath.Join(root, fn)
+		}
+
+		out[fn] = strings.ToLower(parts[0])
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "problem reading manifest '%s'", s.Manifest)
+	}
+
+	return out, nil
+}
+
+func (s *checksumSpec) evaluate() (*checksumResults, error) {
+	expected := map[string]string{}
+	for fn, digest := range s.Files {
+		expected[fn] = strings.ToLower(digest)
+	}
+
+	if s.Manifest != "" {
+		manifest, err := s.readManifest()
+		if err != nil {
+			return nil, err
+		}
+
+		for fn, digest := range manifest {
+			expected[fn] = digest
+		}
+	}
+
+	fileNames := make([]string, 0, len(expected))
+	for fn := range expected {
+		fileNames = append(fileNames, fn)
+	}
+	sort.Strings(fileNames)
+
+	results := &checksumResults{}
+	for _, fn := range fileNames {
+		digest := expected[fn]
+
+		hasher, err := newChecksumHash(s.Algorithm, digest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem checking '%s'", fn)
+		}
+
+		f, err := os.Open(fn)
+		if os.IsNotExist(err) {
+			results.missing = append(results.missing, fmt.Sprintf("    %s", fn))
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "problem opening '%s'", fn)
+		}
+
+		_, err = io.Copy(hasher, f)
+		grip.CatchWarning(f.Close())
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem reading '%s'", fn)
+		}
+
+		actual := hex.EncodeToString(hasher.Sum(nil))
+		if actual != digest {
+			results.mismatched = append(results.mismatched,
+				fmt.Sprintf("    %s: expected %s, got %s", fn, digest, actual))
+			continue
+		}
+
+		results.matched = append(results.matched, fn)
+	}
+
+	return results, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Implementation of Checks for File Checksums
+//
+////////////////////////////////////////////////////////////////////////
+
+type fileChecksum struct {
+	checksumSpec `bson:",inline" json:",inline" yaml:",inline"`
+	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *fileChecksum) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	results, err := c.evaluate()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	grip.Debugf("checksum check '%s': %d matched, %d mismatched, %d missing",
+		c.ID(), len(results.matched), len(results.mismatched), len(results.missing))
+
+	if results.failures() > 0 {
+		c.setState(false)
+		c.setMessage(results.messages())
+		c.AddError(errors.Errorf("%d of %d files do not match their expected checksums",
+			results.failures(), results.failures()+len(results.matched)))
+		return
+	}
+
+	c.setState(true)
+}
+
+type fileChecksumGroup struct {
+	checksumSpec `bson:",inline" json:",inline" yaml:",inline"`
+	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *fileChecksumGroup) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.Requirements.Validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	results, err := c.evaluate()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	result, err := c.Requirements.GetResults(len(results.matched), results.failures())
+	c.setState(result)
+	c.AddError(err)
+
+	if !result {
+		msgs := results.messages()
+		if len(results.matched) > 0 {
+			msgs = append(msgs, fmt.Sprintf("%d matching file(s): %s", len(results.matched),
+				strings.Join(results.matched, ", ")))
+		}
+
+		c.setMessage(msgs)
+		c.AddError(errors.New("group of file checksums do not satisfy check requirements"))
+	}
+}
@@ -0,0 +1,159 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// digests of the string "greenbay\n"
+const (
+	greenbayMD5    = "de8d7e4aaa7c7b31d9ecbb56f5c734e3"
+	greenbaySHA1   = "21675c93579ecb7776a18c8791ce397169379776"
+	greenbaySHA256 = "a1204f0203751c7bb253a7876ba8441c2da0edfd1bbf5f5b073e285508b34274"
+)
+
+func TestFileChecksumCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-checksum-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "file")
+	missing := filepath.Join(dir, "missing")
+	require.NoError(ioutil.WriteFile(fn, []byte("greenbay\n"), 0644))
+
+	manifest := filepath.Join(dir, "SHA256SUMS")
+	require.NoError(ioutil.WriteFile(manifest, []byte(fmt.Sprintf("%s  file\n%s *missing\n",
+		greenbaySHA256, greenbaySHA256)), 0644))
+
+	cases := []struct {
+		spec   checksumSpec
+		passes bool
+	}{
+		{checksumSpec{}, false},
+		{checksumSpec{Algorithm: "crc32", Files: map[string]string{fn: greenbayMD5}}, false},
+		{checksumSpec{Files: map[string]string{fn: greenbayMD5}}, true},
+		{checksumSpec{Files: map[string]string{fn: greenbaySHA1}}, true},
+		{checksumSpec{Files: map[string]string{fn: strings.ToUpper(greenbaySHA256)}}, true},
+		{checksumSpec{Algorithm: "sha256", Files: map[string]string{fn: greenbaySHA256}}, true},
+		{checksumSpec{Algorithm: "md5", Files: map[string]string{fn: greenbaySHA256}}, false},
+		{checksumSpec{Files: map[string]string{fn: strings.Repeat("0", 64)}}, false},
+		{checksumSpec{Files: map[string]string{fn: "00"}}, false},
+		{checksumSpec{Files: map[string]string{missing: greenbayMD5}}, false},
+		{checksumSpec{Manifest: manifest}, false},
+		{checksumSpec{Manifest: manifest + ".DOES-NOT-EXIST"}, false},
+	}
+
+	for idx, c := range cases {
+		check := &fileChecksum{
+			checksumSpec: c.spec,
+			Base:         NewBase("file-checksum", 0),
+		}
+		check.Run()
+		output := check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(check.Error())
+		} else {
+			assert.Error(check.Error())
+		}
+	}
+
+	// the manifest should report missing files separately from
+	// mismatched files.
+	require.NoError(ioutil.WriteFile(manifest, []byte(fmt.Sprintf("%s  file\n%s *missing\n",
+		strings.Repeat("0", 64), greenbaySHA256)), 0644))
+	check := &fileChecksum{
+		checksumSpec: checksumSpec{Manifest: manifest},
+		Base:         NewBase("file-checksum", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "1 file(s) with mismatched checksums:\n    "+fn)
+	assert.Contains(output.Message, "1 missing file(s):\n    "+missing)
+}
+
+func TestFileChecksumGroupCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-checksum-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "file")
+	require.NoError(ioutil.WriteFile(fn, []byte("greenbay\n"), 0644))
+	files := map[string]string{
+		fn:                            greenbaySHA256,
+		filepath.Join(dir, "missing"): greenbaySHA256,
+	}
+
+	expected := map[string]bool{
+		"file-checksum-group-all":  false,
+		"file-checksum-group-any":  true,
+		"file-checksum-group-one":  true,
+		"file-checksum-group-none": false,
+	}
+
+	for name, passes := range expected {
+		factory, err := registry.GetJobFactory(name)
+		require.NoError(err)
+		check, ok := factory().(*fileChecksumGroup)
+		require.True(ok)
+
+		check.Files = files
+		check.Run()
+		output := check.Output()
+		assert.True(output.Completed)
+		assert.Equal(passes, output.Passed, name)
+		if passes {
+			assert.NoError(check.Error(), name)
+		} else {
+			assert.Error(check.Error(), name)
+		}
+	}
+}
+
+func TestFileChecksumSerialization(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	spec := checksumSpec{
+		Algorithm: "sha256",
+		Files:     map[string]string{"/etc/hosts": greenbaySHA256},
+		Manifest:  "/srv/app/SHA256SUMS",
+		Root:      "/srv/app",
+	}
+
+	for _, format := range []amboy.Format{amboy.BSON, amboy.JSON, amboy.YAML} {
+		check := &fileChecksum{checksumSpec: spec, Base: NewBase("file-checksum", 0)}
+		data, err := amboy.ConvertTo(format, check)
+		require.NoError(err)
+
+		out := &fileChecksum{Base: NewBase("file-checksum", 0)}
+		require.NoError(amboy.ConvertFrom(format, data, out))
+		assert.Equal(spec, out.checksumSpec, "format %d", format)
+
+		group := &fileChecksumGroup{checksumSpec: spec, Base: NewBase("file-checksum-group-all", 0)}
+		data, err = amboy.ConvertTo(format, group)
+		require.NoError(err)
+
+		outGroup := &fileChecksumGroup{Base: NewBase("file-checksum-group-all", 0)}
+		require.NoError(amboy.ConvertFrom(format, data, outGroup))
+		assert.Equal(spec, outGroup.checksumSpec, "format %d", format)
+	}
+}
@@ -0,0 +1,236 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "file-contents"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &fileContents{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// fileContents checks the content of a single file. Literal
+// (Contains/NotContains) and regular expression
+// (Matches/NotMatches) assertions operate on the file line by line,
+// so literals cannot contain newlines, and LinePattern, MinLines, and
+// MaxLines make it possible to assert the number of lines that match
+// a pattern. A MaxLines value of 0 means that there is no upper bound.
+type fileContents struct {
+	FileName    string   `bson:"name" json:"name" yaml:"name"`
+	Contains    []string `bson:"contains" json:"contains" yaml:"contains"`
+	NotContains []string `bson:"not_contains" json:"not_contains" yaml:"not_contains"`
+	Matches     []string `bson:"matches" json:"matches" yaml:"matches"`
+	NotMatches  []string `bson:"not_matches" json:"not_matches" yaml:"not_matches"`
+	LinePattern string   `bson:"line_pattern" json:"line_pattern" yaml:"line_pattern"`
+	MinLines    int      `bson:"min_lines" json:"min_lines" yaml:"min_lines"`
+	MaxLines    int      `bson:"max_lines" json:"max_lines" yaml:"max_lines"`
+	*Base       `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *fileContents) hasLineCount() bool {
+	return c.LinePattern != "" || c.MinLines > 0 || c.MaxLines > 0
+}
+
+func (c *fileContents) validate() error {
+	if c.FileName == "" {
+		return errors.Errorf("no file specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if len(c.Contains)+len(c.NotContains)+len(c.Matches)+len(c.NotMatches) == 0 && !c.hasLineCount() {
+		return errors.Errorf("no content assertions specified for file '%s'", c.FileName)
+	}
+
+	for _, literal := range append(append([]string{}, c.Contains...), c.NotContains...) {
+		if strings.ContainsAny(literal, "\r\n") {
+			return errors.Errorf("literal %q for file '%s' spans lines, which line by line "+
+				"assertions cannot match; use a pattern for each line", literal, c.FileName)
+		}
+	}
+
+	if c.MinLines < 0 || c.MaxLines < 0 {
+		return errors.Errorf("line counts for file '%s' cannot be negative [min=%d, max=%d]",
+			c.FileName, c.MinLines, c.MaxLines)
+	}
+
+	if c.MaxLines > 0 && c.MinLines > c.MaxLines {
+		return errors.Errorf("minimum line count %d is larger than maximum %d for file '%s'",
+			c.MinLines, c.MaxLines, c.FileName)
+	}
+
+	return nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem compiling pattern '%s'", p)
+		}
+		out = append(out, re)
+	}
+
+	return out, nil
+}
+
+func formatLine(num int, line string) string {
+	return fmt.Sprintf("    %d: %s", num, line)
+}
+
+// evaluate reads the file and runs all configured assertions,
+// returning a list of messages that describe every assertion that
+// failed, including the offending lines and their line numbers.
+func (c *fileContents) evaluate() ([]string, error) {
+	matches, err := compilePatterns(c.Matches)
+	if err != nil {
+		return nil, err
+	}
+
+	notMatches, err := compilePatterns(c.NotMatches)
+	if err != nil {
+		return nil, err
+	}
+
+	var linePattern *regexp.Regexp
+	if c.LinePattern != "" {
+		linePattern, err = regexp.Compile(c.LinePattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem compiling line pattern '%s'", c.LinePattern)
+		}
+	}
+
+	data, err := ioutil.ReadFile(c.FileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading file '%s'", c.FileName)
+	}
+
+	// an empty file has no lines, rather than one empty line.
+	content := string(data)
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimRight(content, "\n"), "\n")
+	}
+
+	var problems []string
+
+	// literals do not span lines, so searching the whole file finds
+	// the same literals as searching each line.
+	for _, literal := range c.Contains {
+		if !strings.Contains(content, literal) {
+			problems = append(problems, fmt.Sprintf("file '%s' does not contain '%s'",
+				c.FileName, literal))
+		}
+	}
+
+	for _, re := range matches {
+		found := false
+		for _, line := range lines {
+			if re.MatchString(line) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			problems = append(problems, fmt.Sprintf("no lines in file '%s' match '%s'",
+				c.FileName, re.String()))
+		}
+	}
+
+	for _, literal := range c.NotContains {
+		var offending []string
+		for idx, line := range lines {
+			if strings.Contains(line, literal) {
+				offending = append(offending, formatLine(idx+1, line))
+			}
+		}
+
+		if len(offending) > 0 {
+			problems = append(problems, fmt.Sprintf("file '%s' contains '%s' on %d line(s):",
+				c.FileName, literal, len(offending)))
+			problems = append(problems, offending...)
+		}
+	}
+
+	for _, re := range notMatches {
+		var offending []string
+		for idx, line := range lines {
+			if re.MatchString(line) {
+				offending = append(offending, formatLine(idx+1, line))
+			}
+		}
+
+		if len(offending) > 0 {
+			problems = append(problems, fmt.Sprintf("%d line(s) in file '%s' match '%s':",
+				len(offending), c.FileName, re.String()))
+			problems = append(problems, offending...)
+		}
+	}
+
+	if c.hasLineCount() {
+		var counted []string
+		for idx, line := range lines {
+			if linePattern == nil || linePattern.MatchString(line) {
+				counted = append(counted, formatLine(idx+1, line))
+			}
+		}
+
+		if len(counted) < c.MinLines {
+			problems = append(problems, fmt.Sprintf("file '%s' has %d line(s) matching '%s', "+
+				"fewer than the minimum of %d", c.FileName, len(counted), c.LinePattern, c.MinLines))
+		}
+
+		if c.MaxLines > 0 && len(counted) > c.MaxLines {
+			problems = append(problems, fmt.Sprintf("file '%s' has %d line(s) matching '%s', "+
+				"more than the maximum of %d:", c.FileName, len(counted), c.LinePattern, c.MaxLines))
+			problems = append(problems, counted...)
+		}
+	}
+
+	return problems, nil
+}
+
+func (c *fileContents) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	problems, err := c.evaluate()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	grip.Debugf("file contents check '%s' found %d problems with '%s'",
+		c.ID(), len(problems), c.FileName)
+
+	if len(problems) > 0 {
+		c.setState(false)
+		c.setMessage(problems)
+		c.AddError(errors.Errorf("contents of file '%s' do not satisfy check requirements",
+			c.FileName))
+		return
+	}
+
+	c.setState(true)
+}
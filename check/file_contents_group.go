@@ -0,0 +1,62 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/greenbay"
+	"github.com/pkg/errors"
+)
+
+func registerFileContentsGroupChecks() {
+	fileContentsGroupFactoryFactory := func(name string, gr GroupRequirements) func() amboy.Job {
+		gr.Name = name
+		return func() amboy.Job {
+			return &fileContentsGroup{
+				Base:         NewBase(name, 0),
+				Requirements: gr,
+			}
+		}
+	}
+
+	for group, requirements := range groupRequirementRegistry {
+		name := fmt.Sprintf("file-contents-group-%s", group)
+		registry.AddJobType(name, fileContentsGroupFactoryFactory(name, requirements))
+	}
+}
+
+type fileContentsGroup struct {
+	Checks       []*fileContents   `bson:"checks" json:"checks" yaml:"checks"`
+	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *fileContentsGroup) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.Requirements.Validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if len(c.Checks) == 0 {
+		c.setState(false)
+		c.AddError(errors.Errorf("no file content checks specified for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
+	checks := make([]greenbay.Checker, 0, len(c.Checks))
+	for idx, check := range c.Checks {
+		if check.Base == nil {
+			check.Base = NewBase(fmt.Sprintf("%s-%d", c.ID(), idx), c.Type().Version)
+		}
+
+		checks = append(checks, check)
+	}
+
+	c.runGroupChecks(c.Requirements, checks)
+}
@@ -0,0 +1,88 @@
+package check
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type FileContentsGroupSuite struct {
+	fileName string
+	group    *fileContentsGroup
+	require  *require.Assertions
+	suite.Suite
+}
+
+func TestFileContentsGroupSuite(t *testing.T) {
+	suite.Run(t, new(FileContentsGroupSuite))
+}
+
+func (s *FileContentsGroupSuite) SetupSuite() {
+	s.require = s.Require()
+	s.fileName = writeContentsFixture(s.T(), "net:\n  port: 27017\n")
+}
+
+func (s *FileContentsGroupSuite) TearDownSuite() {
+	s.NoError(os.Remove(s.fileName))
+}
+
+func (s *FileContentsGroupSuite) SetupTest() {
+	factory, err := registry.GetJobFactory("file-contents-group-all")
+	s.require.NoError(err)
+	check, ok := factory().(*fileContentsGroup)
+	s.require.True(ok)
+	s.group = check
+}
+
+func (s *FileContentsGroupSuite) TestWithNoChecks() {
+	s.group.Run()
+
+	output := s.group.Output()
+	s.True(output.Completed)
+	s.False(output.Passed)
+	s.Error(s.group.Error())
+}
+
+func (s *FileContentsGroupSuite) TestAllRequirementWithPassingChecks() {
+	s.group.Checks = []*fileContents{
+		{FileName: s.fileName, Contains: []string{"port: 27017"}},
+		{FileName: s.fileName, NotMatches: []string{"bindIp"}},
+	}
+
+	s.group.Run()
+	output := s.group.Output()
+	s.True(output.Completed)
+	s.True(output.Passed)
+	s.NoError(s.group.Error())
+}
+
+func (s *FileContentsGroupSuite) TestAllRequirementWithFailingCheck() {
+	s.group.Checks = []*fileContents{
+		{FileName: s.fileName, Contains: []string{"port: 27017"}},
+		{FileName: s.fileName, NotContains: []string{"port"}},
+	}
+
+	s.group.Run()
+	output := s.group.Output()
+	s.True(output.Completed)
+	s.False(output.Passed)
+	s.Error(s.group.Error())
+	s.Contains(output.Message, "2:   port: 27017")
+}
+
+func (s *FileContentsGroupSuite) TestAnyRequirementWithOnePassingCheck() {
+	s.group.Requirements = GroupRequirements{Any: true, Name: "file-contents-group-any"}
+	s.group.Checks = []*fileContents{
+		{FileName: s.fileName, Contains: []string{"port: 27017"}},
+		{FileName: s.fileName, NotContains: []string{"port"}},
+	}
+
+	s.group.Run()
+	output := s.group.Output()
+	s.True(output.Completed)
+	s.True(output.Passed)
+	s.NoError(s.group.Error())
+}
@@ -0,0 +1,162 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeContentsFixture(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "greenbay-contents-")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+
+	return f.Name()
+}
+
+func TestFileContentsValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &fileContents{Base: NewBase("file-contents", 0)}
+	assert.Error(check.validate())
+
+	check.FileName = "foo"
+	assert.Error(check.validate())
+
+	check.Contains = []string{"bar"}
+	assert.NoError(check.validate())
+
+	check.MinLines = 4
+	check.MaxLines = 2
+	assert.Error(check.validate())
+
+	check.MaxLines = -1
+	assert.Error(check.validate())
+
+	check.MaxLines = 0
+	assert.NoError(check.validate())
+
+	// literals apply to single lines
+	check.Contains = []string{"port = 27017\nfork = false"}
+	assert.Error(check.validate())
+	check.Contains = nil
+	check.NotContains = []string{"fork = true\r\n"}
+	assert.Error(check.validate())
+}
+
+func TestFileContentsCheckMultiLineLiteral(t *testing.T) {
+	assert := assert.New(t)
+
+	fn := writeContentsFixture(t, "port = 27017\nfork = true\n")
+	defer os.Remove(fn)
+
+	// a literal that spans lines fails the check, rather than never
+	// matching a line and passing.
+	check := &fileContents{
+		FileName:    fn,
+		NotContains: []string{"port = 27017\nfork = true"},
+		Base:        NewBase("file-contents", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Error(check.Error())
+	assert.Contains(output.Error, "spans lines")
+}
+
+func TestFileContentsCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	fn := writeContentsFixture(t, "bind_ip = 127.0.0.1\nport = 27017\n# fork = true\nfork = false\n")
+	defer os.Remove(fn)
+
+	cases := []struct {
+		check  *fileContents
+		passes bool
+	}{
+		{&fileContents{Contains: []string{"port = 27017"}}, true},
+		{&fileContents{Contains: []string{"port = 27018"}}, false},
+		{&fileContents{NotContains: []string{"fork = true"}}, false},
+		{&fileContents{NotContains: []string{"auth"}}, true},
+		{&fileContents{Matches: []string{`^port\s*=\s*\d+$`}}, true},
+		{&fileContents{Matches: []string{`^auth`}}, false},
+		{&fileContents{NotMatches: []string{`^fork\s*=\s*true`}}, true},
+		{&fileContents{NotMatches: []string{`^#`}}, false},
+		{&fileContents{LinePattern: "fork", MinLines: 2}, true},
+		{&fileContents{LinePattern: "fork", MaxLines: 1}, false},
+		{&fileContents{MinLines: 4, MaxLines: 4}, true},
+		{&fileContents{MinLines: 5}, false},
+		{&fileContents{Matches: []string{"("}}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.FileName = fn
+		c.check.Base = NewBase("file-contents", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+
+	// failures should report the offending lines with line numbers
+	check := &fileContents{
+		FileName:    fn,
+		NotContains: []string{"fork"},
+		Base:        NewBase("file-contents", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "3: # fork = true")
+	assert.Contains(output.Message, "4: fork = false")
+
+	// missing files should fail with an error
+	check = &fileContents{
+		FileName: fn + ".DOES-NOT-EXIST",
+		Contains: []string{"foo"},
+		Base:     NewBase("file-contents", 0),
+	}
+	check.Run()
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+}
+
+func TestFileContentsCheckEmptyFile(t *testing.T) {
+	assert := assert.New(t)
+
+	fn := writeContentsFixture(t, "")
+	defer os.Remove(fn)
+
+	for _, c := range []struct {
+		check  *fileContents
+		passes bool
+	}{
+		{&fileContents{MinLines: 1}, false},
+		{&fileContents{NotMatches: []string{`^$`}}, true},
+		{&fileContents{Matches: []string{`^$`}}, false},
+	} {
+		c.check.FileName = fn
+		c.check.Base = NewBase("file-contents", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%+v: %s", c.check, output.Message)
+	}
+
+	check := &fileContents{FileName: fn, MinLines: 1, Base: NewBase("file-contents", 0)}
+	check.Run()
+	assert.Contains(check.Output().Message, "has 0 line(s)")
+}
@@ -1,6 +1,12 @@
 package check
 
-import "github.com/pkg/errors"
+import (
+	"strings"
+
+	"github.com/mongodb/greenbay"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
 
 // this is populated in init.go's init(), to avoid init() ordering
 // effects. Only used during the init process, so we don't need locks
@@ -69,3 +75,59 @@ func (gr GroupRequirements) Validate() error {
 
 	return nil
 }
+
+// runGroupChecks runs the member checks of a group check in order,
+// and sets the state of the group from the requirements. When the
+// group fails, its message and errors come from the member checks
+// that explain the failure: the checks that passed for "none" groups,
+// all checks for "any" and "one" groups, and the checks that failed
+// otherwise.
+func (b *Base) runGroupChecks(gr GroupRequirements, checks []greenbay.Checker) {
+	var success []*greenbay.CheckOutput
+	var failure []*greenbay.CheckOutput
+
+	for _, check := range checks {
+		check.Run()
+
+		result := check.Output()
+		if result.Passed {
+			success = append(success, &result)
+		} else {
+			failure = append(failure, &result)
+		}
+	}
+
+	result, err := gr.GetResults(len(success), len(failure))
+	b.setState(result)
+	b.AddError(err)
+	grip.Debugf("task '%s' received result %t, with %d successes and %d failures",
+		b.ID(), result, len(success), len(failure))
+
+	if !result {
+		var output []string
+		var errs []string
+		var printableResults []*greenbay.CheckOutput
+
+		if gr.None {
+			printableResults = success
+		} else if gr.Any || gr.One {
+			printableResults = success
+			printableResults = append(printableResults, failure...)
+		} else {
+			printableResults = failure
+		}
+
+		for _, check := range printableResults {
+			if check.Message != "" {
+				output = append(output, check.Message)
+			}
+
+			if check.Error != "" {
+				errs = append(errs, check.Error)
+			}
+		}
+
+		b.setMessage(output)
+		b.AddError(errors.New(strings.Join(errs, "\n")))
+	}
+}
@@ -1,13 +1,79 @@
 package check
 
 func init() {
-	packageCheckerRegistry = map[string]packageChecker{
-		"yum":    packageCheckerFactory([]string{"yum", "list", "installed"}),
-		"dpkg":   packageCheckerFactory([]string{"dpkg", "-l"}),
-		"brew":   packageCheckerFactory([]string{"brew", "list"}),
-		"pacman": packageCheckerFactory([]string{"pacman", "-Q"}),
-		"pip":    packageCheckerFactory([]string{"pip", "show"}),
-		"gem":    packageCheckerFactory([]string{"gem", "list", "-i"}),
+	// yum, dnf, and zypper all install packages into the rpm
+	// database, so they share a single inventory.
+	rpmInventory := &packageInventory{
+		args:   []string{"rpm", "-qa", "--queryformat", "%{NAME} %{ARCH} %|EPOCH?{%{EPOCH}:}:{}|%{VERSION}-%{RELEASE}\\n"},
+		parse:  parseRPMInventory,
+		scheme: "rpm",
+	}
+
+	// package managers that add their own releases or revisions to
+	// upstream versions (e.g. "2.17-317.el7", "1.1.1f_1", or
+	// "1.2.3-r4") use the rpm or dpkg schemes, so that releases
+	// sort after the upstream version. gem, snap, and flatpak report
+	// upstream versions, where suffixes mark pre-releases.
+	packageInventoryRegistry = map[string]*packageInventory{
+		"yum":    rpmInventory,
+		"rpm":    rpmInventory,
+		"dnf":    rpmInventory,
+		"zypper": rpmInventory,
+		"dpkg": &packageInventory{
+			args:   []string{"dpkg-query", "-W", "-f=${Package} ${Architecture} ${Version} ${Status}\\n"},
+			parse:  parseDpkgInventory,
+			scheme: "dpkg",
+		},
+		"brew": &packageInventory{
+			args:   []string{"brew", "list", "--versions"},
+			parse:  parseFieldsInventory,
+			scheme: "rpm",
+		},
+		"pacman": &packageInventory{
+			args:   []string{"pacman", "-Q"},
+			parse:  parseFieldsInventory,
+			scheme: "rpm",
+		},
+		"pip": &packageInventory{
+			args:            []string{"pip", "list", "--format=freeze"},
+			parse:           parsePipInventory,
+			normalize:       normalizePythonPackageName,
+			scheme:          "pep440",
+			interpreterArgs: []string{"-m", "pip", "list", "--format=freeze"},
+			virtualenv:      true,
+		},
+		"gem": &packageInventory{
+			args:            []string{"gem", "list", "--local"},
+			parse:           parseGemInventory,
+			scheme:          "loose",
+			interpreterArgs: []string{"-S", "gem", "list", "--local"},
+		},
+		"apk": &packageInventory{
+			args:   []string{"apk", "info", "-v"},
+			parse:  parseApkInventory,
+			scheme: "rpm",
+		},
+		"snap": &packageInventory{
+			args:   []string{"snap", "list"},
+			parse:  parseSnapInventory,
+			scheme: "loose",
+		},
+		"flatpak": &packageInventory{
+			args:   []string{"flatpak", "list", "--columns=application,version"},
+			parse:  parseFlatpakInventory,
+			scheme: "loose",
+		},
+		"npm": &packageInventory{
+			args:           []string{"npm", "ls", "--global", "--json", "--depth=0"},
+			parse:          parseNpmInventory,
+			scheme:         "semver",
+			ignoreExitCode: true,
+		},
+		"cargo": &packageInventory{
+			args:   []string{"cargo", "install", "--list"},
+			parse:  parseCargoInventory,
+			scheme: "semver",
+		},
 	}
 
 	groupRequirementRegistry = map[string]GroupRequirements{
@@ -17,12 +83,16 @@ func init() {
 		"none": GroupRequirements{None: true},
 	}
 
-	registerPackageChecks()       // from package.go
-	registerPackageGroupChecks()  // from package_group.go
-	registerFileGroupChecks()     // from file_group_exists.go
-	registerCommandGroupChecks()  // from command_group.go
-	registerSystemLimitChecks()   // from limit.go
-	registerProgramChecks()       // from program.go
-	registerProgramReturnChecks() // from program_return.go
-	registerCompileChecks()       // from compile.go
+	registerPackageChecks()           // from package.go
+	registerPackageGroupChecks()      // from package_group.go
+	registerFileGroupChecks()         // from file_group_exists.go
+	registerCommandGroupChecks()      // from command_group.go
+	registerSystemLimitChecks()       // from limit.go
+	registerProgramChecks()           // from program.go
+	registerProgramReturnChecks()     // from program_return.go
+	registerCompileChecks()           // from compile.go
+	registerFileContentsGroupChecks() // from file_contents_group.go
+	registerFileChecksumGroupChecks() // from file_checksum.go
+	registerSysctlGroupChecks()       // from sysctl_group.go
+	registerDiskFreeGroupChecks()     // from disk_free_group.go
 }
@@ -1,41 +1,118 @@
 package check
 
 import (
+	"fmt"
+	"math"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
 func registerSystemLimitChecks() {
-	limitCheckFactoryFactory := func(name string, cfunc limitValueCheck) func() amboy.Job {
+	limitCheckFactoryFactory := func(name string, cfunc limitValueCheck, getter resourceLimitGetter) func() amboy.Job {
 		return func() amboy.Job {
 			return &limitCheck{
-				Base:      NewBase(name, 0),
-				limitTest: cfunc,
+				Base:          NewBase(name, 0),
+				limitTest:     cfunc,
+				resourceLimit: getter,
 			}
 		}
 	}
 
+	getters := resourceLimitGetterTable()
 	for name, checkFunc := range limitValueCheckTable() {
-		registry.AddJobType(name, limitCheckFactoryFactory(name, checkFunc))
+		registry.AddJobType(name, limitCheckFactoryFactory(name, checkFunc, getters[name]))
 	}
 }
 
+// resourceLimitNames are the names of the checks for resource limits
+// (rlimits). Platforms that do not support a limit register an
+// undefined check with its name, so that configs can be shared
+// between platforms.
+var resourceLimitNames = []string{
+	"open-files",
+	"address-size",
+	"max-processes",
+	"stack-size",
+	"core-size",
+	"locked-memory",
+	"file-size",
+	"cpu-time",
+	"data-size",
+	"resident-set-size",
+	"message-queue-size",
+	"nice-limit",
+	"realtime-priority",
+}
+
+// rlimitUnlimited is the value of unlimited soft and hard limits
+// returned by resourceLimitGetters, on all platforms.
+const rlimitUnlimited uint64 = math.MaxUint64
+
 type limitValueCheck func(int) (bool, error)
 
+// resourceLimitGetter returns the soft and hard values of a resource
+// limit of the greenbay process.
+type resourceLimitGetter func() (soft uint64, hard uint64, err error)
+
+// limitCheck checks a system limit. For resource limits, Soft and Hard
+// are the minimum soft and hard limits: either numbers or "unlimited",
+// which only unlimited limits satisfy. Value is the original form of
+// the hard limit check, where -1 requires the platform's default
+// maximum, and cannot be combined with Hard.
 type limitCheck struct {
-	Value     int `bson:"value" json:"value" yaml:"value"`
-	*Base     `bson:"metadata" json:"metadata" yaml:"metadata"`
-	limitTest limitValueCheck
+	Value         int    `bson:"value" json:"value" yaml:"value"`
+	Soft          string `bson:"soft" json:"soft" yaml:"soft"`
+	Hard          string `bson:"hard" json:"hard" yaml:"hard"`
+	*Base         `bson:"metadata" json:"metadata" yaml:"metadata"`
+	limitTest     limitValueCheck
+	resourceLimit resourceLimitGetter
+}
+
+func parseLimitValue(value string) (uint64, error) {
+	if strings.ToLower(strings.TrimSpace(value)) == "unlimited" {
+		return rlimitUnlimited, nil
+	}
+
+	out, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("limit '%s' is not a number or 'unlimited'", value)
+	}
+
+	return out, nil
+}
+
+func formatLimitValue(value uint64) string {
+	if value == rlimitUnlimited {
+		return "unlimited"
+	}
+
+	return strconv.FormatUint(value, 10)
 }
 
 func (c *limitCheck) Run() {
 	c.startTask()
 	defer c.MarkComplete()
 
+	if c.Soft != "" || c.Hard != "" {
+		c.checkResourceLimit()
+		return
+	}
+
 	result, err := c.limitTest(c.Value)
+	if c.resourceLimit != nil {
+		soft, hard, getErr := c.resourceLimit()
+		if getErr == nil {
+			c.setMessage(fmt.Sprintf("%s limit: soft=%s hard=%s", c.Name(),
+				formatLimitValue(soft), formatLimitValue(hard)))
+		}
+	}
+
 	if err != nil {
 		c.setState(false)
 		c.AddError(err)
@@ -51,6 +128,89 @@ func (c *limitCheck) Run() {
 	c.setState(true)
 }
 
+// checkResourceLimit compares the soft and hard values of a resource
+// limit to the minimums in the check, and reports both values.
+func (c *limitCheck) checkResourceLimit() {
+	if c.resourceLimit == nil {
+		c.setState(false)
+		c.AddError(errors.Errorf("limit check %s does not support soft and hard limits on this platform (%s)",
+			c.Name(), runtime.GOOS))
+		return
+	}
+
+	if c.Value != 0 && c.Hard != "" {
+		c.setState(false)
+		c.AddError(errors.Errorf("limit check '%s' may specify value or hard, but not both", c.ID()))
+		return
+	}
+
+	soft, hard, err := c.resourceLimit()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("%s limit: soft=%s hard=%s", c.Name(),
+		formatLimitValue(soft), formatLimitValue(hard)))
+
+	catcher := grip.NewCatcher()
+	catcher.Add(compareResourceLimit(c.Name(), c.Soft, c.Hard, soft, hard))
+
+	if c.Value != 0 {
+		result, err := c.limitTest(c.Value)
+		catcher.Add(err)
+		if err == nil && !result {
+			catcher.Add(errors.Errorf("limit in check %s is incorrect", c.ID()))
+		}
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+// compareResourceLimit returns an error if the soft or hard value of
+// a limit is less than the expected minimum. Empty expectations are
+// not checked.
+func compareResourceLimit(name, expectedSoft, expectedHard string, soft, hard uint64) error {
+	catcher := grip.NewCatcher()
+	for _, limit := range []struct {
+		kind     string
+		expected string
+		actual   uint64
+	}{
+		{"soft", expectedSoft, soft},
+		{"hard", expectedHard, hard},
+	} {
+		if limit.expected == "" {
+			continue
+		}
+
+		expected, err := parseLimitValue(limit.expected)
+		if err != nil {
+			catcher.Add(err)
+			continue
+		}
+
+		if limit.actual < expected {
+			if expected == rlimitUnlimited {
+				catcher.Add(errors.Errorf("%s %s limit is %s, expected unlimited",
+					limit.kind, name, formatLimitValue(limit.actual)))
+			} else {
+				catcher.Add(errors.Errorf("%s %s limit is %s, expected at least %d",
+					limit.kind, name, formatLimitValue(limit.actual), expected))
+			}
+		}
+	}
+
+	return catcher.Resolve()
+}
+
 func undefinedLimitCheckFactory(name string) limitValueCheck {
 	return func(_ int) (bool, error) {
 		return false, errors.Errorf("limit check %s is not defined on this platform (%s)",
@@ -0,0 +1,28 @@
+// +build linux,!mips,!mipsle,!mips64,!mips64le,!sparc64
+
+package check
+
+// the syscall package does not define these limits for linux. These
+// are the values from asm-generic/resource.h, which mips and sparc do
+// not use.
+const (
+	rlimitRSS      = 5
+	rlimitNPROC    = 6
+	rlimitMEMLOCK  = 8
+	rlimitMSGQUEUE = 12
+	rlimitNICE     = 13
+	rlimitRTPRIO   = 14
+)
+
+func resourceLimitTable() map[string]resourceLimit {
+	m := commonResourceLimits()
+
+	m["resident-set-size"] = resourceLimit{rlimitRSS, rlimitUnlimited}
+	m["max-processes"] = resourceLimit{rlimitNPROC, rlimitUnlimited}
+	m["locked-memory"] = resourceLimit{rlimitMEMLOCK, rlimitUnlimited}
+	m["message-queue-size"] = resourceLimit{rlimitMSGQUEUE, rlimitUnlimited}
+	m["nice-limit"] = resourceLimit{rlimitNICE, 40}
+	m["realtime-priority"] = resourceLimit{rlimitRTPRIO, 99}
+
+	return m
+}
@@ -0,0 +1,9 @@
+// +build freebsd solaris darwin linux,mips linux,mipsle linux,mips64 linux,mips64le linux,sparc64
+
+package check
+
+// on these platforms, greenbay only checks the limits that the
+// syscall package defines.
+func resourceLimitTable() map[string]resourceLimit {
+	return commonResourceLimits()
+}
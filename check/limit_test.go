@@ -32,3 +32,57 @@ func TestLimitCheckImplementation(t *testing.T) {
 	assert.Error(check.Error())
 	assert.False(check.Output().Passed)
 }
+
+func TestResourceLimitCheckSoftAndHard(t *testing.T) {
+	assert := assert.New(t)
+
+	getter := func(soft, hard uint64) resourceLimitGetter {
+		return func() (uint64, uint64, error) { return soft, hard, nil }
+	}
+
+	cases := []struct {
+		soft     string
+		hard     string
+		value    int
+		getter   resourceLimitGetter
+		expected bool
+	}{
+		{soft: "1024", getter: getter(1024, 4096), expected: true},
+		{soft: "2048", getter: getter(1024, 4096), expected: false},
+		{hard: "4096", getter: getter(1024, 4096), expected: true},
+		{soft: "1024", hard: "unlimited", getter: getter(1024, 4096), expected: false},
+		{soft: "unlimited", hard: "unlimited", getter: getter(rlimitUnlimited, rlimitUnlimited), expected: true},
+		{soft: "1000000", getter: getter(rlimitUnlimited, rlimitUnlimited), expected: true},
+		{soft: "lots", getter: getter(1024, 4096), expected: false},
+		{hard: "1024", value: 1024, getter: getter(1024, 4096), expected: false},
+		{soft: "1024", getter: nil, expected: false},
+	}
+
+	for idx, test := range cases {
+		check := &limitCheck{
+			Soft:          test.soft,
+			Hard:          test.hard,
+			Value:         test.value,
+			Base:          NewBase("open-files", 0),
+			limitTest:     func(_ int) (bool, error) { return true, nil },
+			resourceLimit: test.getter,
+		}
+		check.Run()
+		output := check.Output()
+		assert.Equal(test.expected, output.Passed, "%d: %s", idx, output.Error)
+		if test.getter != nil && test.soft != "lots" && test.value == 0 {
+			assert.Contains(output.Message, "open-files limit: soft=", "%d", idx)
+		}
+	}
+
+	check := &limitCheck{
+		Soft:          "unlimited",
+		Base:          NewBase("core-size", 0),
+		resourceLimit: getter(0, rlimitUnlimited),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Equal("core-size limit: soft=0 hard=unlimited", output.Message)
+	assert.Contains(output.Error, "soft core-size limit is 0, expected unlimited")
+}
@@ -13,7 +13,46 @@ import (
 // define a "limitValueCheckTable" function in all files which returns
 // a map of "limit name" to check function.
 
+// resourceLimit describes a resource limit, and the default maximum
+// that checks with a value of -1 require of the hard limit.
+type resourceLimit struct {
+	resource int
+	max      uint64
+}
+
+// rlimInfinity is the platform's value for unlimited limits, which
+// is negative on some platforms.
+const rlimInfinity uint64 = syscall.RLIM_INFINITY & (1<<64 - 1)
+
 func limitValueCheckTable() map[string]limitValueCheck {
+	m := map[string]limitValueCheck{
+		"irp-stack-size": undefinedLimitCheckFactory("irp-stack-size"),
+	}
+
+	resources := resourceLimitTable()
+	for _, name := range resourceLimitNames {
+		if limit, ok := resources[name]; ok {
+			m[name] = limitCheckFactory(name, limit.resource, limit.max)
+		} else {
+			m[name] = undefinedLimitCheckFactory(name)
+		}
+	}
+
+	return m
+}
+
+func resourceLimitGetterTable() map[string]resourceLimitGetter {
+	m := map[string]resourceLimitGetter{}
+	for name, limit := range resourceLimitTable() {
+		m[name] = rlimitGetter(limit.resource)
+	}
+
+	return m
+}
+
+// commonResourceLimits returns the limits that all supported unix
+// platforms define.
+func commonResourceLimits() map[string]resourceLimit {
 	var addressLimit uint64
 
 	if runtime.GOARCH == "386" {
@@ -22,18 +61,40 @@ func limitValueCheckTable() map[string]limitValueCheck {
 		addressLimit = 18446744073709551000
 	}
 
-	return map[string]limitValueCheck{
-		"open-files":     limitCheckFactory("open-files", syscall.RLIMIT_NOFILE, 128000),
-		"address-size":   limitCheckFactory("address-size", syscall.RLIMIT_AS, addressLimit),
-		"irp-stack-size": undefinedLimitCheckFactory("irp-stack-size"),
+	return map[string]resourceLimit{
+		"open-files":   {syscall.RLIMIT_NOFILE, 128000},
+		"address-size": {syscall.RLIMIT_AS, addressLimit},
+		"stack-size":   {syscall.RLIMIT_STACK, rlimitUnlimited},
+		"core-size":    {syscall.RLIMIT_CORE, rlimitUnlimited},
+		"file-size":    {syscall.RLIMIT_FSIZE, rlimitUnlimited},
+		"cpu-time":     {syscall.RLIMIT_CPU, rlimitUnlimited},
+		"data-size":    {syscall.RLIMIT_DATA, rlimitUnlimited},
 	}
 }
 
-func limitCheckFactory(name string, resource int, max uint64) limitValueCheck {
-	return func(value int) (bool, error) {
+func rlimitGetter(resource int) resourceLimitGetter {
+	normalize := func(value uint64) uint64 {
+		if value == rlimInfinity {
+			return rlimitUnlimited
+		}
+		return value
+	}
+
+	return func() (uint64, uint64, error) {
 		limits := &syscall.Rlimit{}
+		if err := syscall.Getrlimit(resource, limits); err != nil {
+			return 0, 0, errors.Wrapf(err, "problem finding limit for resource %d", resource)
+		}
+
+		return normalize(uint64(limits.Cur)), normalize(uint64(limits.Max)), nil
+	}
+}
 
-		err := syscall.Getrlimit(resource, limits)
+func limitCheckFactory(name string, resource int, max uint64) limitValueCheck {
+	getter := rlimitGetter(resource)
+
+	return func(value int) (bool, error) {
+		_, hard, err := getter()
 		if err != nil {
 			return false, errors.Wrapf(err, "problem finding %s limit", name)
 		}
@@ -43,9 +104,9 @@ func limitCheckFactory(name string, resource int, max uint64) limitValueCheck {
 			expected = max
 		}
 
-		if limits.Max < expected {
-			return false, errors.Errorf("%s limit is %d which is less than %d",
-				name, limits.Max, value)
+		if hard < expected {
+			return false, errors.Errorf("%s limit is %s which is less than %s",
+				name, formatLimitValue(hard), formatLimitValue(expected))
 		}
 
 		return true, nil
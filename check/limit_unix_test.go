@@ -3,6 +3,7 @@
 package check
 
 import (
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,3 +24,37 @@ func TestLimitCheckFactoryNegativeValue(t *testing.T) {
 		assert.True(result)
 	}
 }
+
+func TestResourceLimitGetters(t *testing.T) {
+	assert := assert.New(t)
+
+	getters := resourceLimitGetterTable()
+	checks := limitValueCheckTable()
+	for _, name := range resourceLimitNames {
+		assert.Contains(checks, name)
+	}
+
+	for name, getter := range getters {
+		soft, hard, err := getter()
+		assert.NoError(err, name)
+		assert.True(soft <= hard, name)
+	}
+
+	limits := &syscall.Rlimit{}
+	assert.NoError(syscall.Getrlimit(syscall.RLIMIT_NOFILE, limits))
+	soft, hard, err := getters["open-files"]()
+	assert.NoError(err)
+	assert.Equal(uint64(limits.Cur), soft)
+	assert.Equal(uint64(limits.Max), hard)
+
+	check := &limitCheck{
+		Soft:          formatLimitValue(soft),
+		Hard:          formatLimitValue(hard),
+		Base:          NewBase("open-files", 0),
+		limitTest:     checks["open-files"],
+		resourceLimit: getters["open-files"],
+	}
+	check.Run()
+	assert.True(check.Output().Passed, check.Output().Error)
+	assert.Contains(check.Output().Message, "open-files limit: soft="+formatLimitValue(soft))
+}
@@ -18,13 +18,17 @@ func limitValueCheckTable() map[string]limitValueCheck {
 
 	// we have to define UNIX tests here as "invalid checks" so
 	// windows and unix systems can use the same config
-	for _, name := range []string{"open-files", "address-size"} {
+	for _, name := range resourceLimitNames {
 		m[name] = undefinedLimitCheckFactory(name)
 	}
 
 	return m
 }
 
+func resourceLimitGetterTable() map[string]resourceLimitGetter {
+	return map[string]resourceLimitGetter{}
+}
+
 func irpStackSize(value int) (bool, error) {
 	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\services\LanmanServer\Parameters`, registry.QUERY_VALUE)
 	if err != nil {
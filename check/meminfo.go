@@ -0,0 +1,151 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "memory"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &memoryCheck{
+			Base:     NewBase(name, 0),
+			procRoot: "/proc",
+		}
+	})
+}
+
+// memoryCheck checks the total memory and swap of the system, from
+// /proc/meminfo. MinTotal and MinSwap are the minimum sizes, in bytes
+// with an optional K, M, or G suffix (e.g. "16G"). This check is only
+// available on linux.
+type memoryCheck struct {
+	MinTotal string `bson:"min_total" json:"min_total" yaml:"min_total"`
+	MinSwap  string `bson:"min_swap" json:"min_swap" yaml:"min_swap"`
+	*Base    `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	procRoot string
+}
+
+// parseMeminfo returns the values in /proc/meminfo, in bytes.
+func parseMeminfo(fn string) (map[string]uint64, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening '%s'", fn)
+	}
+	defer f.Close()
+
+	out := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem parsing '%s' in '%s'", parts[0], fn)
+		}
+
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+
+		out[parts[0]] = value
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "problem reading '%s'", fn)
+	}
+
+	return out, nil
+}
+
+// formatByteSize formats a size in bytes with the largest binary
+// suffix that parseByteSize accepts.
+func formatByteSize(size uint64) string {
+	for idx, suffix := range []string{"G", "M", "K"} {
+		unit := uint64(1) << (10 * uint(3-idx))
+		if size >= unit {
+			return strconv.FormatFloat(float64(size)/float64(unit), 'f', 1, 64) + suffix
+		}
+	}
+
+	return strconv.FormatUint(size, 10)
+}
+
+func (c *memoryCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if c.MinTotal == "" && c.MinSwap == "" {
+		c.setState(false)
+		c.AddError(errors.Errorf("no memory sizes specified for '%s' (%s) check", c.ID(), c.Name()))
+		return
+	}
+
+	meminfo, err := parseMeminfo(filepath.Join(c.procRoot, "meminfo"))
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("total memory: %s, swap: %s",
+		formatByteSize(meminfo["MemTotal"]), formatByteSize(meminfo["SwapTotal"])))
+
+	catcher := grip.NewCatcher()
+	for _, size := range []struct {
+		kind     string
+		field    string
+		expected string
+	}{
+		{"total memory", "MemTotal", c.MinTotal},
+		{"swap", "SwapTotal", c.MinSwap},
+	} {
+		if size.expected == "" {
+			continue
+		}
+
+		expected, err := parseByteSize(size.expected)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "invalid minimum %s", size.kind))
+			continue
+		}
+
+		actual, ok := meminfo[size.field]
+		if !ok {
+			catcher.Add(errors.Errorf("meminfo does not report %s", size.field))
+			continue
+		}
+
+		if actual < expected {
+			catcher.Add(errors.Errorf("%s is %s, expected at least %s",
+				size.kind, formatByteSize(actual), size.expected))
+		}
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
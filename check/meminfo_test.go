@@ -0,0 +1,89 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMeminfo = `MemTotal:       16318484 kB
+MemFree:         2815380 kB
+HugePages_Total:       0
+SwapTotal:       2097148 kB
+SwapFree:        2097148 kB
+`
+
+func TestParseMeminfo(t *testing.T) {
+	assert := assert.New(t)
+	root, err := ioutil.TempDir("", "greenbay-meminfo")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	fn := filepath.Join(root, "meminfo")
+	require.NoError(t, ioutil.WriteFile(fn, []byte(testMeminfo), 0644))
+
+	meminfo, err := parseMeminfo(fn)
+	assert.NoError(err)
+	assert.Equal(uint64(16318484*1024), meminfo["MemTotal"])
+	assert.Equal(uint64(2097148*1024), meminfo["SwapTotal"])
+	assert.Equal(uint64(0), meminfo["HugePages_Total"])
+
+	_, err = parseMeminfo(filepath.Join(root, "missing"))
+	assert.Error(err)
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("0", formatByteSize(0))
+	assert.Equal("512", formatByteSize(512))
+	assert.Equal("1.5K", formatByteSize(1536))
+	assert.Equal("2.0G", formatByteSize(2<<30))
+}
+
+func TestMemoryCheck(t *testing.T) {
+	root, err := ioutil.TempDir("", "greenbay-meminfo")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "meminfo"), []byte(testMeminfo), 0644))
+
+	factory, err := registry.GetJobFactory("memory")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		total  string
+		swap   string
+		passes bool
+	}{
+		"EnoughMemory":    {"8G", "", true},
+		"EnoughSwap":      {"", "1900M", true},
+		"EnoughOfBoth":    {"15G", "1G", true},
+		"NotEnoughMemory": {"32G", "", false},
+		"NotEnoughSwap":   {"8G", "4G", false},
+		"InvalidSize":     {"lots", "", false},
+		"NoSizes":         {"", "", false},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*memoryCheck)
+			check.procRoot = root
+			check.MinTotal = test.total
+			check.MinSwap = test.swap
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if test.total != "" || test.swap != "" {
+				assert.Equal(t, "total memory: 15.6G, swap: 2.0G", output.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "mount"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &mountCheck{
+			Base:     NewBase(name, 0),
+			procRoot: "/proc",
+		}
+	})
+}
+
+// mountCheck checks the mount that contains a path, as reported by
+// /proc/self/mountinfo. Symbolic links in Path are resolved, and the
+// containing mount is the mount with the longest mount point that
+// contains the resolved path. MountPoint requires the path to be a
+// mount point itself, rather than a directory on another mount (e.g.
+// on the root filesystem).
+//
+// FileSystem is the expected filesystem type (e.g. "xfs"). Options
+// must, and NotOptions must not, be among the mount's options, which
+// include both the per-mount options (e.g. "noatime") and the
+// filesystem's options (e.g. "inode64"). Options match either the
+// whole option or its name, so "size" matches "size=1g". This check is
+// only available on linux.
+type mountCheck struct {
+	Path       string   `bson:"path" json:"path" yaml:"path"`
+	MountPoint bool     `bson:"mount_point" json:"mount_point" yaml:"mount_point"`
+	FileSystem string   `bson:"fs_type" json:"fs_type" yaml:"fs_type"`
+	Options    []string `bson:"options" json:"options" yaml:"options"`
+	NotOptions []string `bson:"not_options" json:"not_options" yaml:"not_options"`
+	*Base      `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	procRoot string
+}
+
+// mountInfo is a mount from /proc/self/mountinfo.
+type mountInfo struct {
+	mountPoint string
+	fsType     string
+	source     string
+	options    []string
+}
+
+// unescapeMountField replaces the octal escapes (e.g. "\040" for a
+// space) that the kernel uses in the fields of mountinfo.
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+
+	var out bytes.Buffer
+	for idx := 0; idx < len(field); idx++ {
+		if field[idx] == '\\' && idx+4 <= len(field) {
+			if value, err := strconv.ParseUint(field[idx+1:idx+4], 8, 8); err == nil {
+				out.WriteByte(byte(value))
+				idx += 3
+				continue
+			}
+		}
+		out.WriteByte(field[idx])
+	}
+
+	return out.String()
+}
+
+// parseMountInfo parses mountinfo, which has lines of the form:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// where the optional fields before the "-" separator vary in number.
+func parseMountInfo(fn string) ([]*mountInfo, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening '%s'", fn)
+	}
+	defer f.Close()
+
+	var out []*mountInfo
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		separator := -1
+		for idx := 6; idx < len(fields); idx++ {
+			if fields[idx] == "-" {
+				separator = idx
+				break
+			}
+		}
+
+		if separator < 0 || len(fields) < separator+3 {
+			return nil, errors.Errorf("problem parsing line '%s' in '%s'", scanner.Text(), fn)
+		}
+
+		mount := &mountInfo{
+			mountPoint: unescapeMountField(fields[4]),
+			fsType:     fields[separator+1],
+			source:     unescapeMountField(fields[separator+2]),
+			options:    strings.Split(fields[5], ","),
+		}
+
+		// the per-mount options determine if the mount is
+		// read-only, whatever the filesystem's options are.
+		if len(fields) > separator+3 {
+			for _, option := range strings.Split(fields[separator+3], ",") {
+				if option != "ro" && option != "rw" && !mount.hasOption(option) {
+					mount.options = append(mount.options, option)
+				}
+			}
+		}
+
+		out = append(out, mount)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "problem reading '%s'", fn)
+	}
+
+	return out, nil
+}
+
+// containingMount returns the mount that contains the path. When
+// several mounts share a mount point, the last one hides the others.
+func containingMount(mounts []*mountInfo, path string) *mountInfo {
+	var out *mountInfo
+	for _, mount := range mounts {
+		prefix := mount.mountPoint
+		if prefix != "/" {
+			prefix += "/"
+		}
+
+		if path != mount.mountPoint && !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		if out == nil || len(mount.mountPoint) >= len(out.mountPoint) {
+			out = mount
+		}
+	}
+
+	return out
+}
+
+func (m *mountInfo) hasOption(option string) bool {
+	for _, opt := range m.options {
+		if opt == option || strings.SplitN(opt, "=", 2)[0] == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *mountCheck) validate() error {
+	if c.Path == "" {
+		return errors.Errorf("no path specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if !c.MountPoint && c.FileSystem == "" && len(c.Options)+len(c.NotOptions) == 0 {
+		return errors.Errorf("no mount assertions specified for path '%s'", c.Path)
+	}
+
+	return nil
+}
+
+// resolvePath returns the absolute path with symbolic links resolved.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "problem finding absolute path of '%s'", path)
+	}
+
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", errors.Wrapf(err, "problem resolving path '%s'", path)
+	}
+
+	return resolved, nil
+}
+
+func (c *mountCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	path, err := resolvePath(c.Path)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	mounts, err := parseMountInfo(filepath.Join(c.procRoot, "self", "mountinfo"))
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	mount := containingMount(mounts, path)
+	if mount == nil {
+		c.setState(false)
+		c.AddError(errors.Errorf("no mount contains path '%s'", c.Path))
+		return
+	}
+
+	description := fmt.Sprintf("path '%s' is on %s (%s from %s): %s", c.Path,
+		mount.mountPoint, mount.fsType, mount.source, strings.Join(mount.options, ","))
+
+	var problems []string
+
+	if c.MountPoint && mount.mountPoint != path {
+		problems = append(problems, fmt.Sprintf("path '%s' is not a mount point", c.Path))
+	}
+
+	if c.FileSystem != "" && mount.fsType != c.FileSystem {
+		problems = append(problems, fmt.Sprintf("mount %s is %s, expected %s",
+			mount.mountPoint, mount.fsType, c.FileSystem))
+	}
+
+	for _, option := range c.Options {
+		if !mount.hasOption(option) {
+			problems = append(problems, fmt.Sprintf("mount %s does not have option '%s'",
+				mount.mountPoint, option))
+		}
+	}
+
+	for _, option := range c.NotOptions {
+		if mount.hasOption(option) {
+			problems = append(problems, fmt.Sprintf("mount %s has option '%s'",
+				mount.mountPoint, option))
+		}
+	}
+
+	c.setMessage(append([]string{description}, problems...))
+
+	if len(problems) > 0 {
+		c.setState(false)
+		c.AddError(errors.Errorf("mount of path '%s' does not satisfy check requirements", c.Path))
+		return
+	}
+
+	c.setState(true)
+}
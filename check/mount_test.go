@@ -0,0 +1,139 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnescapeMountField(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/data", unescapeMountField("/data"))
+	assert.Equal("/mnt/my disk", unescapeMountField(`/mnt/my\040disk`))
+	assert.Equal(`/mnt/trailing\04`, unescapeMountField(`/mnt/trailing\04`))
+}
+
+func TestContainingMount(t *testing.T) {
+	assert := assert.New(t)
+	mounts := []*mountInfo{
+		{mountPoint: "/", fsType: "ext4"},
+		{mountPoint: "/data", fsType: "ext4"},
+		{mountPoint: "/data", fsType: "xfs"},
+		{mountPoint: "/data/db/journal", fsType: "tmpfs"},
+	}
+
+	assert.Equal("ext4", containingMount(mounts, "/").fsType)
+	assert.Equal("ext4", containingMount(mounts, "/database").fsType)
+	assert.Equal("xfs", containingMount(mounts, "/data").fsType)
+	assert.Equal("xfs", containingMount(mounts, "/data/db").fsType)
+	assert.Equal("tmpfs", containingMount(mounts, "/data/db/journal/file").fsType)
+	assert.Nil(containingMount(mounts[1:], "/tmp"))
+}
+
+func TestMountCheck(t *testing.T) {
+	root, err := ioutil.TempDir("", "greenbay-mount")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	root, err = filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+
+	data := filepath.Join(root, "data")
+	tmp := filepath.Join(root, "tmp dir")
+	for _, dir := range []string{filepath.Join(data, "db"), tmp, filepath.Join(root, "proc", "self")} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, os.Symlink(filepath.Join(data, "db"), filepath.Join(root, "link")))
+
+	mountinfo := strings.Join([]string{
+		"22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro",
+		fmt.Sprintf("30 22 8:17 / %s rw,noatime shared:12 - xfs /dev/sdb1 rw,attr2,inode64,noquota", data),
+		fmt.Sprintf("31 22 0:40 / %s rw,nosuid,nodev,noexec - tmpfs tmpfs rw,size=1048576k",
+			strings.Replace(tmp, " ", `\040`, -1)),
+	}, "\n") + "\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "proc", "self", "mountinfo"), []byte(mountinfo), 0644))
+
+	factory, err := registry.GetJobFactory("mount")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		check  mountCheck
+		passes bool
+	}{
+		"DataIsXFS":              {mountCheck{Path: data, MountPoint: true, FileSystem: "xfs", Options: []string{"noatime"}}, true},
+		"DataIsNotExt4":          {mountCheck{Path: data, FileSystem: "ext4"}, false},
+		"DataHasFilesystemOpts":  {mountCheck{Path: data, Options: []string{"inode64"}}, true},
+		"DataDoesNotHaveNoexec":  {mountCheck{Path: data, NotOptions: []string{"noexec"}}, true},
+		"DataIsNotNodiratime":    {mountCheck{Path: data, Options: []string{"nodiratime"}}, false},
+		"SubdirectoryIsOnData":   {mountCheck{Path: filepath.Join(data, "db"), FileSystem: "xfs"}, true},
+		"SubdirectoryIsNotMount": {mountCheck{Path: filepath.Join(data, "db"), MountPoint: true}, false},
+		"SymlinkIsResolved":      {mountCheck{Path: filepath.Join(root, "link"), FileSystem: "xfs"}, true},
+		"TmpIsNoexec":            {mountCheck{Path: tmp, NotOptions: []string{"noexec"}}, false},
+		"OptionNameMatches":      {mountCheck{Path: tmp, Options: []string{"size"}}, true},
+		"OptionValueMatches":     {mountCheck{Path: tmp, Options: []string{"size=1048576k"}}, true},
+		"OptionValueDiffers":     {mountCheck{Path: tmp, Options: []string{"size=2g"}}, false},
+		"RootIsExt4":             {mountCheck{Path: root, FileSystem: "ext4"}, true},
+		"MissingPath":            {mountCheck{Path: filepath.Join(root, "missing"), FileSystem: "ext4"}, false},
+		"NoPath":                 {mountCheck{FileSystem: "ext4"}, false},
+		"NoAssertions":           {mountCheck{Path: data}, false},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*mountCheck)
+			base := check.Base
+			*check = test.check
+			check.Base = base
+			check.procRoot = filepath.Join(root, "proc")
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if test.passes {
+				assert.NoError(t, check.Error())
+				assert.Contains(t, output.Message, "is on ")
+			} else {
+				assert.Error(t, check.Error())
+			}
+		})
+	}
+}
+
+func TestMountCheckWithSystemMounts(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("mountinfo is only available on linux")
+	}
+
+	check := &mountCheck{Path: "/", MountPoint: true, Base: NewBase("mount", 0), procRoot: "/proc"}
+	check.Run()
+
+	output := check.Output()
+	assert.True(t, output.Passed, output.Error)
+	assert.Contains(t, output.Message, "path '/' is on / (")
+}
+
+func TestParseMountInfoMergesOptions(t *testing.T) {
+	assert := assert.New(t)
+	fn := filepath.Join(os.TempDir(), "greenbay-mountinfo")
+	require.NoError(t, ioutil.WriteFile(fn, []byte("28 1 254:0 / / ro,relatime - ext4 /dev/vda rw,discard\n"), 0644))
+	defer os.Remove(fn)
+
+	mounts, err := parseMountInfo(fn)
+	assert.NoError(err)
+	assert.Len(mounts, 1)
+	assert.Equal([]string{"ro", "relatime", "discard"}, mounts[0].options)
+
+	require.NoError(t, ioutil.WriteFile(fn, []byte("28 1 254:0 / / rw,relatime\n"), 0644))
+	_, err = parseMountInfo(fn)
+	assert.Error(err)
+}
@@ -0,0 +1,117 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "numa-nodes"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &numaNodesCheck{
+			Base:    NewBase(name, 0),
+			sysRoot: "/sys",
+		}
+	})
+}
+
+var numaNodePattern = regexp.MustCompile(`^node[0-9]+$`)
+
+// numaNodesCheck checks the number of NUMA nodes in
+// /sys/devices/system/node. MinNodes and MaxNodes bound the number of
+// nodes, and a MaxNodes value of 0 means that there is no upper bound:
+// for example, a MaxNodes value of 1 requires a system without NUMA.
+// Kernels without NUMA support have a single node. This check is only
+// available on linux.
+type numaNodesCheck struct {
+	MinNodes int `bson:"min_nodes" json:"min_nodes" yaml:"min_nodes"`
+	MaxNodes int `bson:"max_nodes" json:"max_nodes" yaml:"max_nodes"`
+	*Base    `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	sysRoot string
+}
+
+func (c *numaNodesCheck) validate() error {
+	if c.MinNodes == 0 && c.MaxNodes == 0 {
+		return errors.Errorf("no node counts specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.MinNodes < 0 || c.MaxNodes < 0 {
+		return errors.Errorf("node counts for '%s' (%s) check cannot be negative [min=%d, max=%d]",
+			c.ID(), c.Name(), c.MinNodes, c.MaxNodes)
+	}
+
+	if c.MaxNodes > 0 && c.MinNodes > c.MaxNodes {
+		return errors.Errorf("minimum node count %d is larger than maximum %d for '%s' (%s) check",
+			c.MinNodes, c.MaxNodes, c.ID(), c.Name())
+	}
+
+	return nil
+}
+
+// numaNodes returns the names of the NUMA nodes.
+func numaNodes(sysRoot string) ([]string, error) {
+	dir := filepath.Join(sysRoot, "devices", "system", "node")
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		// kernels without NUMA support do not have the node
+		// directory, and have a single node.
+		return []string{"node0"}, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "problem listing NUMA nodes in '%s'", dir)
+	}
+
+	var out []string
+	for _, entry := range entries {
+		if numaNodePattern.MatchString(entry.Name()) {
+			out = append(out, entry.Name())
+		}
+	}
+
+	return out, nil
+}
+
+func (c *numaNodesCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	nodes, err := numaNodes(c.sysRoot)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("%d NUMA node(s): %s", len(nodes), strings.Join(nodes, ", ")))
+
+	if len(nodes) < c.MinNodes {
+		c.setState(false)
+		c.AddError(errors.Errorf("system has %d NUMA node(s), fewer than the minimum of %d",
+			len(nodes), c.MinNodes))
+		return
+	}
+
+	if c.MaxNodes > 0 && len(nodes) > c.MaxNodes {
+		c.setState(false)
+		c.AddError(errors.Errorf("system has %d NUMA node(s), more than the maximum of %d",
+			len(nodes), c.MaxNodes))
+		return
+	}
+
+	c.setState(true)
+}
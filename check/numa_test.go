@@ -0,0 +1,67 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumaNodesCheck(t *testing.T) {
+	root, err := ioutil.TempDir("", "greenbay-numa")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	dir := filepath.Join(root, "devices", "system", "node")
+	for _, name := range []string{"node0", "node1", "power"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "online"), []byte("0-1\n"), 0644))
+
+	factory, err := registry.GetJobFactory("numa-nodes")
+	require.NoError(t, err)
+
+	// the fixture root without the node directory describes a
+	// kernel without NUMA support.
+	noNUMA := filepath.Join(root, "missing")
+
+	cases := map[string]struct {
+		root    string
+		min     int
+		max     int
+		passes  bool
+		message string
+	}{
+		"WithinBounds":       {root, 1, 2, true, "2 NUMA node(s): node0, node1"},
+		"MinimumOnly":        {root, 2, 0, true, "2 NUMA node(s): node0, node1"},
+		"FewerThanMinimum":   {root, 4, 0, false, ""},
+		"MoreThanMaximum":    {root, 0, 1, false, ""},
+		"NoBounds":           {root, 0, 0, false, ""},
+		"NegativeBounds":     {root, -1, 0, false, ""},
+		"MinimumOverMaximum": {root, 3, 2, false, ""},
+		"WithoutNUMA":        {noNUMA, 1, 1, true, "1 NUMA node(s): node0"},
+		"WithoutNUMAFewer":   {noNUMA, 2, 0, false, ""},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*numaNodesCheck)
+			check.sysRoot = test.root
+			check.MinNodes = test.min
+			check.MaxNodes = test.max
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if test.passes {
+				assert.Equal(t, test.message, output.Message)
+			}
+		})
+	}
+}
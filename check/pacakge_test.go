@@ -1,23 +1,33 @@
 package check
 
 import (
+	"sync/atomic"
 	"testing"
 
+	"github.com/mongodb/amboy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPackageCheckImplementation(t *testing.T) {
 	// this case checks the greenbay.Checker "packageInstalled"
 	// implementation itself. The determination of weather a
-	// package is installed is handled by the packageChecker implementation.
+	// package is installed is handled by the packageInventory.
 
 	assert := assert.New(t)
-	failer := packageCheckerFactory([]string{"python", "-c", "exit(1)"})
-	passer := packageCheckerFactory([]string{"echo", "foo"})
+	passer := &packageInventory{
+		args:  []string{"echo", "foo 1.0"},
+		parse: parseFieldsInventory,
+	}
+	failer := &packageInventory{
+		args:  []string{"echo"},
+		parse: parseFieldsInventory,
+	}
 
 	// if the check passes, as expected
 	check := &packageInstalled{
-		checker:   passer,
+		Package:   "foo",
+		inventory: passer,
 		Base:      NewBase("test", 0),
 		installed: true,
 	}
@@ -27,7 +37,8 @@ func TestPackageCheckImplementation(t *testing.T) {
 
 	// when the check passes but we don't expect it to be installed
 	check = &packageInstalled{
-		checker:   passer,
+		Package:   "foo",
+		inventory: passer,
 		Base:      NewBase("test", 0),
 		installed: false,
 	}
@@ -37,7 +48,8 @@ func TestPackageCheckImplementation(t *testing.T) {
 
 	// if the check fails and we expect it to.
 	check = &packageInstalled{
-		checker:   failer,
+		Package:   "foo",
+		inventory: failer,
 		Base:      NewBase("test", 0),
 		installed: false,
 	}
@@ -48,7 +60,8 @@ func TestPackageCheckImplementation(t *testing.T) {
 
 	// when the check fails and we don't expect it to be installed
 	check = &packageInstalled{
-		checker:   failer,
+		Package:   "foo",
+		inventory: failer,
 		Base:      NewBase("test", 0),
 		installed: true,
 	}
@@ -56,3 +69,157 @@ func TestPackageCheckImplementation(t *testing.T) {
 	assert.Error(check.Error())
 	assert.False(check.Output().Passed)
 }
+
+func TestPackageCheckVersionConstraints(t *testing.T) {
+	assert := assert.New(t)
+	inventory := &packageInventory{
+		args:   []string{"echo", "openssl 1.0.2k-19"},
+		parse:  parseFieldsInventory,
+		scheme: "rpm",
+	}
+
+	cases := []struct {
+		check  *packageInstalled
+		passes bool
+	}{
+		{&packageInstalled{Range: ">=1.0.2k-16", installed: true, inventory: inventory}, true},
+		{&packageInstalled{Range: ">=1.0.2k-20", installed: true, inventory: inventory}, false},
+		{&packageInstalled{Version: "1.0.1", Relationship: "gte", installed: true, inventory: inventory}, true},
+		{&packageInstalled{Version: "1.0.2", Relationship: "gte", installed: true, inventory: inventory}, true},
+		{&packageInstalled{Version: "1.1", Relationship: "gte", installed: true, inventory: inventory}, false},
+		{&packageInstalled{Range: ">=1.0", installed: false, inventory: inventory}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Package = "openssl"
+		c.check.Base = NewBase("test", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+}
+
+func TestPackageCheckWithTool(t *testing.T) {
+	assert := assert.New(t)
+	inventory := &packageInventory{
+		args:            []string{"gem", "list", "--local"},
+		parse:           parseGemInventory,
+		interpreterArgs: []string{"-c", "echo 'rake (12.0.0)'"},
+	}
+
+	check := &packageInstalled{
+		Package:     "rake",
+		packageTool: packageTool{Interpreter: "sh"},
+		Base:        NewBase("gem-installed", 0),
+		installed:   true,
+		inventory:   inventory,
+	}
+	check.Run()
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	check = &packageInstalled{
+		Package:     "rails",
+		packageTool: packageTool{Interpreter: "sh"},
+		Base:        NewBase("gem-installed", 0),
+		installed:   true,
+		inventory:   inventory,
+	}
+	check.Run()
+	assert.Error(check.Error())
+	assert.Contains(check.Output().Message, "checked with sh")
+
+	// problems listing packages fail checks for missing packages,
+	// rather than counting as missing packages.
+	broken := &packageInventory{
+		args:  []string{"false"},
+		parse: parseFieldsInventory,
+	}
+
+	for _, installed := range []bool{true, false} {
+		check = &packageInstalled{
+			Package:   "rake",
+			Base:      NewBase("gem-installed", 0),
+			installed: installed,
+			inventory: broken,
+		}
+		check.Run()
+		assert.Error(check.Error())
+		assert.False(check.Output().Passed)
+		assert.Contains(check.Output().Message, "problem listing installed packages")
+	}
+}
+
+func TestPackageChecksShareRunInventories(t *testing.T) {
+	assert := assert.New(t)
+
+	var loads int32
+	inventory := &packageInventory{
+		args: []string{"echo", "openssl 1.0.2k-19"},
+		parse: func(output string) (map[string]string, error) {
+			atomic.AddInt32(&loads, 1)
+			return parseFieldsInventory(output)
+		},
+	}
+
+	run := func(set *PackageInventories) {
+		for _, check := range []amboy.Job{
+			&packageInstalled{Package: "openssl", installed: true, inventory: inventory, Base: NewBase("test", 0)},
+			&packageInstalled{Package: "zlib", installed: false, inventory: inventory, Base: NewBase("test", 0)},
+			&packageGroup{Packages: []string{"openssl"}, inventory: inventory, Base: NewBase("test", 0),
+				Requirements: GroupRequirements{Name: "test", All: true}},
+		} {
+			UsePackageInventories(check, set)
+			check.Run()
+			assert.NoError(check.Error())
+		}
+	}
+
+	// checks in a run load the inventory once, and each run loads
+	// its own inventory.
+	run(NewPackageInventories())
+	assert.Equal(int32(1), atomic.LoadInt32(&loads))
+	run(NewPackageInventories())
+	assert.Equal(int32(2), atomic.LoadInt32(&loads))
+
+	// checks without a set load the inventory themselves.
+	run(nil)
+	assert.Equal(int32(5), atomic.LoadInt32(&loads))
+
+	// other checks are unaffected.
+	UsePackageInventories(&MockCheck{Base: NewBase("mock", 0)}, NewPackageInventories())
+}
+
+func TestPackageCheckSerialization(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tool := packageTool{
+		Interpreter: "/opt/mongodbtoolchain/v2/bin/python3",
+		RunAs:       &runAsSpec{User: "builder", Groups: []string{"builder"}},
+	}
+
+	for _, format := range []amboy.Format{amboy.BSON, amboy.JSON, amboy.YAML} {
+		check := &packageInstalled{Package: "pymongo", packageTool: tool, Base: NewBase("pip-installed", 0)}
+		data, err := amboy.ConvertTo(format, check)
+		require.NoError(err)
+
+		out := &packageInstalled{Base: NewBase("pip-installed", 0)}
+		require.NoError(amboy.ConvertFrom(format, data, out))
+		assert.Equal(tool, out.packageTool, "format %d", format)
+
+		group := &packageGroup{Packages: []string{"pymongo"}, packageTool: tool, Base: NewBase("pip-group-all", 0)}
+		data, err = amboy.ConvertTo(format, group)
+		require.NoError(err)
+
+		outGroup := &packageGroup{Base: NewBase("pip-group-all", 0)}
+		require.NoError(amboy.ConvertFrom(format, data, outGroup))
+		assert.Equal(tool, outGroup.packageTool, "format %d", format)
+	}
+}
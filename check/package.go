@@ -11,10 +11,10 @@ import (
 // this would be an init function but is simply called from the init()
 // in init.go to avoid ordering effects.
 func registerPackageChecks() {
-	packageCheckerFactoryFactory := func(name string, installed bool, checker packageChecker) func() amboy.Job {
+	packageCheckerFactoryFactory := func(name string, installed bool, inventory *packageInventory) func() amboy.Job {
 		return func() amboy.Job {
 			return &packageInstalled{
-				checker:   checker,
+				inventory: inventory,
 				Base:      NewBase(name, 0),
 				installed: installed,
 			}
@@ -23,28 +23,82 @@ func registerPackageChecks() {
 
 	var name string
 
-	for pkg, checker := range packageCheckerRegistry {
+	for pkg, inventory := range packageInventoryRegistry {
 		name = fmt.Sprintf("%s-installed", pkg)
-		registry.AddJobType(name, packageCheckerFactoryFactory(name, true, checker))
+		registry.AddJobType(name, packageCheckerFactoryFactory(name, true, inventory))
 
 		name = fmt.Sprintf("%s-not-installed", pkg)
-		registry.AddJobType(name, packageCheckerFactoryFactory(name, false, checker))
+		registry.AddJobType(name, packageCheckerFactoryFactory(name, false, inventory))
 	}
 }
 
+// packageInstalled checks that a package is (or is not) installed.
+// Checks for installed packages may also specify a version
+// constraint, using either Range or Version and Relationship, which
+// the installed version of the package must satisfy. VersionScheme
+// overrides the package manager's default version scheme. Checks may
+// specify an interpreter, tool, or virtualenv to use in place of the
+// package manager on the PATH. Checks use the inventory from their
+// run's PackageInventories, if set.
 type packageInstalled struct {
-	Package string `bson:"package" json:"package" yaml:"package"`
-	*Base   `bson:"metadata" json:"metadata" yaml:"metadata"`
+	Package       string `bson:"package" json:"package" yaml:"package"`
+	Version       string `bson:"version" json:"version" yaml:"version"`
+	Relationship  string `bson:"relationship" json:"relationship" yaml:"relationship"`
+	Range         string `bson:"range" json:"range" yaml:"range"`
+	VersionScheme string `bson:"versionScheme" json:"versionScheme" yaml:"versionScheme"`
+	packageTool   `bson:",inline" json:",inline" yaml:",inline"`
+	*Base         `bson:"metadata" json:"metadata" yaml:"metadata"`
 
-	installed bool
-	checker   packageChecker
+	installed   bool
+	inventory   *packageInventory
+	inventories *PackageInventories
+}
+
+func (c *packageInstalled) setPackageInventories(set *PackageInventories) {
+	c.inventories = set
+}
+
+func (c *packageInstalled) hasVersionConstraint() bool {
+	return c.Version != "" || c.Range != ""
+}
+
+func (c *packageInstalled) constraint() versionConstraint {
+	return versionConstraint{
+		Scheme:       c.VersionScheme,
+		Range:        c.Range,
+		Version:      c.Version,
+		Relationship: c.Relationship,
+	}
 }
 
 func (c *packageInstalled) Run() {
 	c.startTask()
 	defer c.MarkComplete()
 
-	exists, msg := c.checker(c.Package)
+	inventory, err := c.inventories.get(c.inventory).forTool(c.packageTool)
+	if err != nil {
+		c.setState(false)
+		c.setMessage(err.Error())
+		c.AddError(errors.Wrapf(err, "problem resolving package tool for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
+	if c.hasVersionConstraint() && !c.installed {
+		c.setState(false)
+		c.AddError(errors.Errorf("cannot specify a version constraint for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
+	exists, msg, err := inventory.installed(c.Package)
+	if err != nil {
+		c.setState(false)
+		c.setMessage(err.Error())
+		c.AddError(errors.Wrapf(err, "problem checking package '%s' for '%s' (%s) check",
+			c.Package, c.ID(), c.Name()))
+		return
+	}
 
 	if !c.installed {
 		// this is the check for "package isn't installed" tasks
@@ -64,5 +118,22 @@ func (c *packageInstalled) Run() {
 	if !exists {
 		c.setMessage(msg)
 		c.AddError(errors.Errorf("package %s does note exist and should", c.Package))
+		return
+	}
+
+	if c.hasVersionConstraint() {
+		result, msg, err := inventory.checkVersion(c.Package, c.constraint())
+		if err != nil {
+			c.setState(false)
+			c.AddError(err)
+			return
+		}
+
+		c.setState(result)
+		if !result {
+			c.setMessage(msg)
+			c.AddError(errors.Errorf("package '%s' is installed but does not satisfy "+
+				"the version constraint", c.Package))
+		}
 	}
 }
@@ -1,30 +1,610 @@
 package check
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
+	"sync"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
 )
 
-type packageChecker func(string) (bool, string)
+// packageInventory holds the packages installed with a package
+// manager, as reported by a single command that lists every installed
+// package (args), which parse converts into a map of package names to
+// versions. The inventories in packageInventoryRegistry describe each
+// package manager, and every run of a set of checks uses its own copy
+// (see PackageInventories), which is loaded the first time any check
+// in the run uses it, so that all checks in the run, including checks
+// running in parallel, see the same snapshot. normalize, if
+// specified, converts package names into the form used as keys in the
+// inventory, and scheme is the default version scheme for versions
+// from this package manager.
+// Some package managers exit with an error while still listing the
+// installed packages (e.g. npm with unmet peer dependencies): set
+// ignoreExitCode to parse their output regardless.
+//
+// Package managers that belong to an interpreter (e.g. pip and gem)
+// set interpreterArgs, the arguments to pass to an interpreter to list
+// its packages, and virtualenv if they support python virtual
+// environments. Inventories for other interpreters or tools are
+// derived from the package manager's inventory, and are cached along
+// with it.
+type packageInventory struct {
+	args            []string
+	parse           func(output string) (map[string]string, error)
+	normalize       func(name string) string
+	scheme          string
+	ignoreExitCode  bool
+	interpreterArgs []string
+	virtualenv      bool
+	identity        *processIdentity
 
-// this is populated in init.go's init(), to avoid init() ordering
-// effects. Only used during the init process, so we don't need locks
-// for this.
-var packageCheckerRegistry map[string]packageChecker
+	mutex    sync.Mutex
+	loaded   bool
+	packages map[string]string
+	err      error
+
+	derivedMutex sync.Mutex
+	derived      map[string]*packageInventory
+}
+
+// packageTool describes an alternate interpreter (e.g.
+// /opt/mongodbtoolchain/v2/bin/python3), package manager binary (e.g.
+// /usr/local/bin/gem), or python virtual environment directory to use
+// to find installed packages, in place of the package manager on the
+// PATH. Only one may be specified. RunAs lists the installed packages
+// as another user, e.g. to check the packages in a user's environment.
+type packageTool struct {
+	Interpreter string     `bson:"interpreter" json:"interpreter" yaml:"interpreter"`
+	Tool        string     `bson:"tool" json:"tool" yaml:"tool"`
+	Virtualenv  string     `bson:"virtualenv" json:"virtualenv" yaml:"virtualenv"`
+	RunAs       *runAsSpec `bson:"run_as" json:"run_as" yaml:"run_as"`
+}
 
-func packageCheckerFactory(args []string) packageChecker {
-	return func(name string) (bool, string) {
-		localArgs := append(args, name)
+func (t packageTool) isSet() bool {
+	return t.Interpreter != "" || t.Tool != "" || t.Virtualenv != "" || t.RunAs != nil
+}
+
+// virtualenvInterpreter returns the path to the python interpreter in
+// a virtual environment.
+func virtualenvInterpreter(dir string) (string, error) {
+	python := filepath.Join(dir, "bin", "python")
+	if runtime.GOOS == "windows" {
+		python = filepath.Join(dir, "Scripts", "python.exe")
+	}
 
-		out, err := exec.Command(localArgs[0], localArgs[1:]...).CombinedOutput()
-		output := strings.Trim(string(out), "\r\t\n ")
+	if _, err := os.Stat(python); err != nil {
+		return "", errors.Wrapf(err, "'%s' is not a virtualenv", dir)
+	}
+
+	return python, nil
+}
 
+// forTool returns the inventory for an alternate tool, or the
+// inventory itself if no tool is specified.
+func (inv *packageInventory) forTool(t packageTool) (*packageInventory, error) {
+	var args []string
+
+	switch {
+	case !t.isSet():
+		return inv, nil
+	case (t.Interpreter != "" && t.Tool != "") || (t.Virtualenv != "" && (t.Interpreter != "" || t.Tool != "")):
+		return nil, errors.New("specify only one of interpreter, tool, or virtualenv")
+	case t.Virtualenv != "":
+		if !inv.virtualenv {
+			return nil, errors.Errorf("%s packages cannot be checked in a virtualenv", inv.args[0])
+		}
+
+		python, err := virtualenvInterpreter(t.Virtualenv)
 		if err != nil {
-			return false, fmt.Sprintf("%s package '%s' is not installed (%s) (%+v): %s",
-				localArgs[0], name, err, output, strings.Join(localArgs, " "))
+			return nil, err
 		}
 
-		return true, output
+		args = append([]string{python}, inv.interpreterArgs...)
+	case t.Interpreter != "":
+		if len(inv.interpreterArgs) == 0 {
+			return nil, errors.Errorf("%s packages cannot be checked with an interpreter", inv.args[0])
+		}
+
+		args = append([]string{t.Interpreter}, inv.interpreterArgs...)
+	case t.Tool != "":
+		args = append([]string{t.Tool}, inv.args[1:]...)
+	default:
+		args = inv.args
+	}
+
+	identity, err := t.RunAs.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	key := strings.Join(args, "\x00") + "\x00" + identity.String()
+
+	inv.derivedMutex.Lock()
+	defer inv.derivedMutex.Unlock()
+
+	if derived, ok := inv.derived[key]; ok {
+		return derived, nil
+	}
+
+	if inv.derived == nil {
+		inv.derived = map[string]*packageInventory{}
+	}
+
+	derived := &packageInventory{
+		args:           args,
+		parse:          inv.parse,
+		normalize:      inv.normalize,
+		scheme:         inv.scheme,
+		ignoreExitCode: inv.ignoreExitCode,
+		identity:       identity,
+	}
+	inv.derived[key] = derived
+
+	return derived, nil
+}
+
+// this is populated in init.go's init(), to avoid init() ordering
+// effects. The registry and its inventories are not modified after
+// init: checks use copies of these inventories from a
+// PackageInventories set.
+var packageInventoryRegistry map[string]*packageInventory
+
+// PackageInventories holds the package inventories for one run of a
+// set of checks, so that every package check in the run sees the same
+// snapshot of the installed packages, while other runs, including
+// concurrent runs in a long running process, have their own.
+type PackageInventories struct {
+	mutex       sync.Mutex
+	inventories map[*packageInventory]*packageInventory
+}
+
+// NewPackageInventories returns an empty set of package inventories.
+// Create a new set for each run of a set of checks, and pass it to
+// UsePackageInventories for each check before running it.
+func NewPackageInventories() *PackageInventories {
+	return &PackageInventories{inventories: map[*packageInventory]*packageInventory{}}
+}
+
+// packageInventoryUser is implemented by checks that use package
+// inventories.
+type packageInventoryUser interface {
+	setPackageInventories(*PackageInventories)
+}
+
+// UsePackageInventories makes a package check use the inventories in
+// the set. Checks that do not use package inventories are unaffected.
+// Package checks without a set query the package database themselves.
+func UsePackageInventories(j amboy.Job, set *PackageInventories) {
+	if c, ok := j.(packageInventoryUser); ok {
+		c.setPackageInventories(set)
+	}
+}
+
+// get returns the set's copy of an inventory, creating it the first
+// time a check in the run uses the inventory. A nil set returns a new
+// copy, which only the calling check uses.
+func (set *PackageInventories) get(inv *packageInventory) *packageInventory {
+	if set == nil {
+		return inv.clone()
+	}
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	if out, ok := set.inventories[inv]; ok {
+		return out
+	}
+
+	out := inv.clone()
+	set.inventories[inv] = out
+	return out
+}
+
+// clone returns a copy of the inventory's configuration, which has
+// not loaded the installed packages.
+func (inv *packageInventory) clone() *packageInventory {
+	return &packageInventory{
+		args:            inv.args,
+		parse:           inv.parse,
+		normalize:       inv.normalize,
+		scheme:          inv.scheme,
+		ignoreExitCode:  inv.ignoreExitCode,
+		interpreterArgs: inv.interpreterArgs,
+		virtualenv:      inv.virtualenv,
+		identity:        inv.identity,
+	}
+}
+
+// load returns the cached inventory, querying the package database if
+// needed. Errors are cached along with the inventory, so that every
+// check in a run reports the same problem.
+func (inv *packageInventory) load() (map[string]string, error) {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	if inv.loaded {
+		return inv.packages, inv.err
+	}
+
+	// the default limits ensure that a package manager that hangs
+	// cannot block the other checks that wait for the inventory.
+	options := commandOptions{identity: inv.identity}.withDefaultLimits()
+	out, err := options.output(exec.Command(inv.args[0], inv.args[1:]...))
+	if _, ok := err.(*exec.ExitError); ok && inv.ignoreExitCode && len(out) > 0 {
+		grip.Debugf("ignoring error listing installed packages (%s): %s",
+			strings.Join(inv.args, " "), err)
+		err = nil
+	}
+
+	if err != nil {
+		inv.err = errors.Wrapf(err, "problem listing installed packages (%s)",
+			strings.Join(inv.args, " "))
+	} else {
+		inv.packages, inv.err = inv.parse(string(out))
+		inv.err = errors.Wrapf(inv.err, "problem parsing installed packages (%s)",
+			strings.Join(inv.args, " "))
+	}
+	inv.loaded = true
+
+	grip.Debugf("loaded inventory of %d packages with '%s'", len(inv.packages),
+		strings.Join(inv.args, " "))
+
+	return inv.packages, inv.err
+}
+
+// lookup returns the installed version of a package, and false if
+// the package is not installed.
+func (inv *packageInventory) lookup(name string) (string, bool, error) {
+	packages, err := inv.load()
+	if err != nil {
+		return "", false, err
+	}
+
+	key := name
+	if inv.normalize != nil {
+		key = inv.normalize(name)
+	}
+
+	version, ok := packages[key]
+	return version, ok, nil
+}
+
+// source describes the binary that listed the installed packages, and
+// the user that ran it, for messages.
+func (inv *packageInventory) source() string {
+	if inv.identity == nil {
+		return inv.args[0]
+	}
+
+	return fmt.Sprintf("%s as %s", inv.args[0], inv.identity)
+}
+
+// installed reports whether a package is installed, with a message
+// that names the binary that listed the installed packages. Problems
+// listing the installed packages are errors, so that checks do not
+// mistake them for missing packages.
+func (inv *packageInventory) installed(name string) (bool, string, error) {
+	version, ok, err := inv.lookup(name)
+	if err != nil {
+		return false, "", err
+	}
+
+	if !ok {
+		return false, fmt.Sprintf("package '%s' is not installed (checked with %s)",
+			name, inv.source()), nil
+	}
+
+	return true, fmt.Sprintf("%s %s (checked with %s)", name, version, inv.source()), nil
+}
+
+// checkVersion reports whether the installed version of the package
+// satisfies the constraint, and returns a message that includes the
+// installed version.
+func (inv *packageInventory) checkVersion(name string, vc versionConstraint) (bool, string, error) {
+	if vc.Scheme == "" {
+		vc.Scheme = inv.scheme
 	}
+
+	if err := vc.validate(); err != nil {
+		return false, "", errors.Wrapf(err, "invalid version constraint for package '%s'", name)
+	}
+
+	version, ok, err := inv.lookup(name)
+	if err != nil {
+		return false, "", err
+	}
+
+	if !ok {
+		return false, "", errors.Errorf("package '%s' is not installed (checked with %s)",
+			name, inv.source())
+	}
+
+	parsed, normalized, err := parseSchemeVersion(vc.Scheme, version)
+	if err != nil {
+		return false, "", err
+	}
+
+	result, err := vc.satisfiedBy(parsed)
+	if err != nil {
+		return false, "", err
+	}
+
+	return result, fmt.Sprintf("package '%s' version is '%s' (normalized: %s), expected %s (checked with %s)",
+		name, version, normalized, vc, inv.source()), nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Parsers for Package Inventories
+//
+////////////////////////////////////////////////////////////////////////
+
+// parseRPMInventory handles lines in the form "<name> <arch>
+// [epoch:]<version>", and records packages by name and by "<name>.<arch>",
+// which is how yum refers to packages for a specific architecture.
+// rpm can install several versions of a package at once (e.g. kernel
+// or gpg-pubkey), in which case the inventory records the highest.
+func parseRPMInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, errors.Errorf("malformed package line '%s'", line)
+		}
+
+		version := fields[2]
+		for _, key := range []string{fields[0], fields[0] + "." + fields[1]} {
+			if current, ok := packages[key]; !ok || rpmVersionLess(current, version) {
+				packages[key] = version
+			}
+		}
+	}
+
+	return packages, nil
+}
+
+// rpmVersionLess reports whether version a sorts before b, using the
+// rpm version scheme. Versions that the scheme cannot parse compare as
+// strings.
+func rpmVersionLess(a, b string) bool {
+	va, _, errA := parseSchemeVersion("rpm", a)
+	vb, _, errB := parseSchemeVersion("rpm", b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+
+	return va.LT(vb)
+}
+
+// parseDpkgInventory handles lines in the form "<name> <arch>
+// <version> <status...>", and only records packages that are
+// installed (rather than removed with their configuration files
+// remaining). Packages are also recorded as "<name>:<arch>".
+func parseDpkgInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 4 {
+			return nil, errors.Errorf("malformed package line '%s'", line)
+		}
+
+		if fields[len(fields)-1] != "installed" {
+			continue
+		}
+
+		version := fields[2]
+		packages[fields[0]] = version
+		packages[fields[0]+":"+fields[1]] = version
+	}
+
+	return packages, nil
+}
+
+// parseFieldsInventory handles lines in the form "<name> <version>
+// ...", as produced by pacman and brew, using the first version on
+// each line.
+func parseFieldsInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, errors.Errorf("malformed package line '%s'", line)
+		}
+
+		packages[fields[0]] = fields[1]
+	}
+
+	return packages, nil
+}
+
+// normalizePythonPackageName converts package names to the form
+// described by PEP 503, which pip uses to compare package names.
+func normalizePythonPackageName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '.' {
+			return '-'
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// parsePipInventory handles the output of "pip list --format=freeze",
+// which has lines in the form "<name>==<version>".
+func parsePipInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "==", 2)
+		if len(parts) != 2 {
+			// editable and vcs installs don't report versions.
+			grip.Debugf("skipping pip package without version '%s'", line)
+			continue
+		}
+
+		packages[normalizePythonPackageName(parts[0])] = parts[1]
+	}
+
+	return packages, nil
+}
+
+// parseGemInventory handles lines in the form "<name> (<version>,
+// <version>)", where the newest version is listed first.
+func parseGemInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "***") {
+			continue
+		}
+
+		idx := strings.Index(line, " (")
+		if idx < 0 || !strings.HasSuffix(line, ")") {
+			return nil, errors.Errorf("malformed gem line '%s'", line)
+		}
+
+		versions := line[idx+2 : len(line)-1]
+		version := strings.TrimSpace(strings.Split(versions, ",")[0])
+		packages[line[:idx]] = strings.TrimPrefix(version, "default: ")
+	}
+
+	return packages, nil
+}
+
+var apkPackagePattern = regexp.MustCompile(`^(.+)-([0-9][^-]*-r[0-9]+)$`)
+
+// parseApkInventory handles the output of "apk info -v", which has
+// lines in the form "<name>-<version>-r<release>". Package names may
+// contain dashes, but versions do not.
+func parseApkInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "WARNING") {
+			continue
+		}
+
+		match := apkPackagePattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, errors.Errorf("malformed package line '%s'", line)
+		}
+
+		packages[match[1]] = match[2]
+	}
+
+	return packages, nil
+}
+
+// parseSnapInventory handles the output of "snap list", which is a
+// table with a header and the name and version in the first two
+// columns.
+func parseSnapInventory(output string) (map[string]string, error) {
+	lines := strings.SplitN(strings.TrimSpace(output), "\n", 2)
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "Name") {
+		// snap prints a message to stderr and no table when
+		// there are no snaps installed.
+		return map[string]string{}, nil
+	}
+
+	return parseFieldsInventory(lines[1])
+}
+
+// parseFlatpakInventory handles tab separated lines in the form
+// "<application id>\t<version>", where the version is often empty for
+// runtimes. Runtimes with multiple branches installed are recorded
+// with the first version listed.
+func parseFlatpakInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		name := strings.TrimSpace(fields[0])
+		if name == "" {
+			continue
+		}
+
+		if _, ok := packages[name]; ok {
+			continue
+		}
+
+		var version string
+		if len(fields) > 1 {
+			version = strings.TrimSpace(fields[1])
+		}
+
+		packages[name] = version
+	}
+
+	return packages, nil
+}
+
+// parseNpmInventory handles the output of "npm ls --global --json
+// --depth=0".
+func parseNpmInventory(output string) (map[string]string, error) {
+	doc := struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}{}
+
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		return nil, errors.Wrap(err, "problem parsing npm output")
+	}
+
+	packages := map[string]string{}
+	for name, info := range doc.Dependencies {
+		packages[name] = info.Version
+	}
+
+	return packages, nil
+}
+
+// parseCargoInventory handles the output of "cargo install --list",
+// which has lines in the form "<name> v<version>:" (or "<name>
+// v<version> (<source>):"), each followed by indented lines that list
+// the binaries the crate installed.
+func parseCargoInventory(output string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(line), ":"))
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "v") {
+			return nil, errors.Errorf("malformed crate line '%s'", line)
+		}
+
+		packages[fields[0]] = strings.TrimSuffix(strings.TrimPrefix(fields[1], "v"), ":")
+	}
+
+	return packages, nil
 }
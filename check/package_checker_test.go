@@ -1,25 +1,361 @@
 package check
 
 import (
-	"strings"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestPackageCheckerFactory(t *testing.T) {
+func TestPackageInventoryLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses a shell")
+	}
+
+	assert := assert.New(t)
+
+	// package managers that write too much output are killed, and
+	// the inventory reports the error.
+	inventory := &packageInventory{
+		args:  []string{"sh", "-c", "echo 'openssl 1.0.2k-19'; exec yes"},
+		parse: parseFieldsInventory,
+	}
+
+	_, _, err := inventory.lookup("openssl")
+	require.Error(t, err)
+	assert.Contains(err.Error(), "exceeded the output limit")
+	assert.Contains(err.Error(), "problem listing installed packages")
+}
+
+func TestPackageInventoryParsers(t *testing.T) {
 	assert := assert.New(t)
 
-	alwaysPasses := packageCheckerFactory([]string{"echo", "passing-test"})
-	alwaysFails := packageCheckerFactory([]string{"exit"})
+	packages, err := parseRPMInventory("glibc x86_64 2.17-317.el7\nglibc i686 2.17-317.el7\ngpg-pubkey (none) f4a80eb5-53a7ff4b\n")
+	assert.NoError(err)
+	assert.Equal("2.17-317.el7", packages["glibc"])
+	assert.Equal("2.17-317.el7", packages["glibc.i686"])
+	assert.Contains(packages, "gpg-pubkey")
+	_, err = parseRPMInventory("glibc 2.17")
+	assert.Error(err)
+
+	// packages installed in several versions report the highest
+	packages, err = parseRPMInventory("kernel x86_64 3.10.0-1160.el7\n" +
+		"kernel x86_64 3.10.0-1160.102.1.el7\n" +
+		"kernel x86_64 3.10.0-957.el7\n" +
+		"gpg-pubkey (none) f4a80eb5-53a7ff4b\n" +
+		"gpg-pubkey (none) 352c64e5-52ae6884\n")
+	assert.NoError(err)
+	assert.Equal("3.10.0-1160.102.1.el7", packages["kernel"])
+	assert.Equal("3.10.0-1160.102.1.el7", packages["kernel.x86_64"])
+	assert.Equal("f4a80eb5-53a7ff4b", packages["gpg-pubkey"])
+
+	// epochs are kept, and take precedence when choosing a version
+	packages, err = parseRPMInventory("openssl x86_64 1:1.0.2k-19.el7\nopenssl i686 1.1.1k-5.el7\n")
+	assert.NoError(err)
+	assert.Equal("1:1.0.2k-19.el7", packages["openssl"])
+	assert.Equal("1:1.0.2k-19.el7", packages["openssl.x86_64"])
+	assert.Equal("1.1.1k-5.el7", packages["openssl.i686"])
 
-	for _, name := range []string{"foo", "bar", "1", "true"} {
-		result, message := alwaysPasses(name)
-		assert.True(result)
-		assert.Equal(message, strings.Join([]string{"passing-test", name}, " "), name)
+	packages, err = parseDpkgInventory("openssl amd64 1:1.1.1f-1ubuntu2.16 install ok installed\n" +
+		"libssl1.0 amd64 1.0.2n-1 deinstall ok config-files\n")
+	assert.NoError(err)
+	assert.Equal("1:1.1.1f-1ubuntu2.16", packages["openssl"])
+	assert.Equal("1:1.1.1f-1ubuntu2.16", packages["openssl:amd64"])
+	assert.NotContains(packages, "libssl1.0")
+	_, err = parseDpkgInventory("openssl amd64")
+	assert.Error(err)
 
-		result, message = alwaysFails(name)
-		assert.False(result)
-		assert.NotEqual("", message)
+	packages, err = parseFieldsInventory("glibc 2.26-1 2.25-3\nzlib 1:1.2.11-4\n")
+	assert.NoError(err)
+	assert.Equal("2.26-1", packages["glibc"])
+	assert.Equal("1:1.2.11-4", packages["zlib"])
+	_, err = parseFieldsInventory("glibc")
+	assert.Error(err)
+
+	packages, err = parsePipInventory("Django==1.11.29\nzope.interface==4.4.3\n-e git+https://example.com/repo.git#egg=foo\n")
+	assert.NoError(err)
+	assert.Equal("1.11.29", packages["django"])
+	assert.Equal("4.4.3", packages["zope-interface"])
+	assert.Len(packages, 2)
+	assert.Equal("zope-interface", normalizePythonPackageName("Zope_Interface"))
+
+	packages, err = parseGemInventory("\n*** LOCAL GEMS ***\n\nrake-compiler (1.0.4)\nrake (default: 12.0.0, 10.4.2)\n")
+	assert.NoError(err)
+	assert.Equal("12.0.0", packages["rake"])
+	assert.Equal("1.0.4", packages["rake-compiler"])
+	_, err = parseGemInventory("rake")
+	assert.Error(err)
+}
+
+func TestPackageInventory(t *testing.T) {
+	assert := assert.New(t)
+
+	inventory := &packageInventory{
+		args:   []string{"echo", "openssl 1.0.2k-19\nglibc 2.17-317.el7"},
+		parse:  parseFieldsInventory,
+		scheme: "rpm",
 	}
+
+	version, ok, err := inventory.lookup("openssl")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal("1.0.2k-19", version)
+
+	_, ok, err = inventory.lookup("zlib")
+	assert.NoError(err)
+	assert.False(ok)
+
+	result, msg, err := inventory.installed("glibc")
+	assert.NoError(err)
+	assert.True(result)
+	assert.Equal("glibc 2.17-317.el7 (checked with echo)", msg)
+	result, msg, err = inventory.installed("zlib")
+	assert.NoError(err)
+	assert.False(result)
+	assert.Contains(msg, "not installed")
+
+	for expr, expected := range map[string]bool{
+		">=1.0.2k-16": true,
+		">=1.0.2k-20": false,
+		">=1.0.2":     true,
+		">1.0.2k":     true,
+		"<1.0.2l":     true,
+		"<1.1":        true,
+	} {
+		result, msg, err = inventory.checkVersion("openssl", versionConstraint{Range: expr})
+		assert.NoError(err, expr)
+		assert.Equal(expected, result, expr)
+		assert.Contains(msg, "'1.0.2k-19'")
+	}
+
+	result, _, err = inventory.checkVersion("openssl", versionConstraint{Version: "1.0.1", Relationship: "gte"})
+	assert.NoError(err)
+	assert.True(result)
+
+	result, _, err = inventory.checkVersion("glibc", versionConstraint{Version: "2.17", Relationship: "gte"})
+	assert.NoError(err)
+	assert.True(result)
+
+	result, _, err = inventory.checkVersion("glibc", versionConstraint{Range: ">=2.17 <2.18"})
+	assert.NoError(err)
+	assert.True(result)
+
+	_, _, err = inventory.checkVersion("openssl", versionConstraint{Range: "=>foo"})
+	assert.Error(err)
+	_, _, err = inventory.checkVersion("zlib", versionConstraint{Range: ">1.0"})
+	assert.Error(err)
+
+	// inventories are cached, including errors, and copies load
+	// the installed packages again.
+	inventory.args = []string{"false"}
+	_, _, err = inventory.lookup("openssl")
+	assert.NoError(err)
+	inventory = inventory.clone()
+	_, _, err = inventory.lookup("openssl")
+	assert.Error(err)
+	result, _, err = inventory.installed("openssl")
+	assert.False(result)
+	assert.Contains(err.Error(), "problem listing installed packages")
+	inventory.args = []string{"echo", "openssl 1.0.2k-19"}
+	_, _, err = inventory.lookup("openssl")
+	assert.Error(err)
+}
+
+func TestPackageInventories(t *testing.T) {
+	assert := assert.New(t)
+
+	inventory := &packageInventory{
+		args:   []string{"echo", "openssl 1.0.2k-19"},
+		parse:  parseFieldsInventory,
+		scheme: "rpm",
+	}
+	other := &packageInventory{args: []string{"echo"}, parse: parseFieldsInventory}
+
+	set := NewPackageInventories()
+	copied := set.get(inventory)
+	assert.False(copied == inventory)
+	assert.Equal(inventory.args, copied.args)
+	assert.Equal("rpm", copied.scheme)
+	assert.True(copied == set.get(inventory))
+	assert.False(copied == set.get(other))
+	assert.False(copied == NewPackageInventories().get(inventory))
+
+	var none *PackageInventories
+	assert.False(none.get(inventory) == none.get(inventory))
+
+	_, _, err := copied.lookup("openssl")
+	assert.NoError(err)
+	assert.False(inventory.loaded)
+}
+
+func TestPackageInventoryLoadsOnceForParallelChecks(t *testing.T) {
+	assert := assert.New(t)
+
+	var loads int32
+	inventory := &packageInventory{
+		args: []string{"echo", "openssl 1.0.2k-19"},
+		parse: func(output string) (map[string]string, error) {
+			atomic.AddInt32(&loads, 1)
+			return parseFieldsInventory(output)
+		},
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := inventory.lookup("openssl")
+			assert.NoError(err)
+			assert.True(ok)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(int32(1), atomic.LoadInt32(&loads))
+
+	_, _, err := inventory.clone().lookup("openssl")
+	assert.NoError(err)
+	assert.Equal(int32(2), atomic.LoadInt32(&loads))
+}
+
+func TestAdditionalPackageInventoryParsers(t *testing.T) {
+	assert := assert.New(t)
+
+	packages, err := parseApkInventory("musl-1.2.2-r7\npy3-pip-20.3.4-r1\nfont-roboto-2.138-r1\n")
+	assert.NoError(err)
+	assert.Equal("1.2.2-r7", packages["musl"])
+	assert.Equal("20.3.4-r1", packages["py3-pip"])
+	assert.Equal("2.138-r1", packages["font-roboto"])
+	_, err = parseApkInventory("musl")
+	assert.Error(err)
+
+	packages, err = parseSnapInventory("Name    Version   Rev    Tracking       Publisher   Notes\n" +
+		"core18  20211215  2284   latest/stable  canonical✓  base\n" +
+		"lxd     4.0.8     21835  4.0/stable/…   canonical✓  -\n")
+	assert.NoError(err)
+	assert.Equal("20211215", packages["core18"])
+	assert.Equal("4.0.8", packages["lxd"])
+	packages, err = parseSnapInventory("")
+	assert.NoError(err)
+	assert.Len(packages, 0)
+
+	packages, err = parseFlatpakInventory("org.gimp.GIMP\t2.10.30\norg.gnome.Platform\t\norg.gnome.Platform\t41\n")
+	assert.NoError(err)
+	assert.Equal("2.10.30", packages["org.gimp.GIMP"])
+	assert.Contains(packages, "org.gnome.Platform")
+	assert.Equal("", packages["org.gnome.Platform"])
+
+	packages, err = parseNpmInventory(`{"dependencies": {"npm": {"version": "8.1.0"}, "typescript": {"version": "4.5.4"}}}`)
+	assert.NoError(err)
+	assert.Equal("8.1.0", packages["npm"])
+	assert.Equal("4.5.4", packages["typescript"])
+	_, err = parseNpmInventory("npm ERR!")
+	assert.Error(err)
+
+	packages, err = parseCargoInventory("ripgrep v13.0.0:\n    rg\nmytool v0.1.0 (/home/user/mytool):\n    mytool\n")
+	assert.NoError(err)
+	assert.Equal("13.0.0", packages["ripgrep"])
+	assert.Equal("0.1.0", packages["mytool"])
+	_, err = parseCargoInventory("ripgrep:\n")
+	assert.Error(err)
+}
+
+func TestPackageInventoryIgnoreExitCode(t *testing.T) {
+	assert := assert.New(t)
+
+	inventory := &packageInventory{
+		args:  []string{"sh", "-c", "echo 'openssl 1.0.2k'; exit 1"},
+		parse: parseFieldsInventory,
+	}
+
+	_, _, err := inventory.lookup("openssl")
+	assert.Error(err)
+
+	inventory = inventory.clone()
+	inventory.ignoreExitCode = true
+	version, ok, err := inventory.lookup("openssl")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal("1.0.2k", version)
+}
+
+func TestPackageInventoryForTool(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("alternate tool tests use a shell")
+	}
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	inventory := &packageInventory{
+		args:            []string{"pip", "list", "--format=freeze"},
+		parse:           parsePipInventory,
+		normalize:       normalizePythonPackageName,
+		interpreterArgs: []string{"-c", "echo Requests==2.18.4"},
+		virtualenv:      true,
+	}
+
+	derived, err := inventory.forTool(packageTool{})
+	assert.NoError(err)
+	assert.True(derived == inventory)
+
+	derived, err = inventory.forTool(packageTool{Interpreter: "sh"})
+	require.NoError(err)
+	assert.Equal([]string{"sh", "-c", "echo Requests==2.18.4"}, derived.args)
+	result, msg, err := derived.installed("requests")
+	assert.NoError(err)
+	assert.True(result)
+	assert.Equal("requests 2.18.4 (checked with sh)", msg)
+
+	again, err := inventory.forTool(packageTool{Interpreter: "sh"})
+	assert.NoError(err)
+	assert.True(derived == again)
+	again, err = inventory.clone().forTool(packageTool{Interpreter: "sh"})
+	assert.NoError(err)
+	assert.False(derived == again)
+
+	derived, err = inventory.forTool(packageTool{Tool: "/bin/echo"})
+	require.NoError(err)
+	assert.Equal([]string{"/bin/echo", "list", "--format=freeze"}, derived.args)
+
+	dir, err := ioutil.TempDir("", "greenbay-venv-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	require.NoError(os.Mkdir(filepath.Join(dir, "bin"), 0755))
+	python := filepath.Join(dir, "bin", "python")
+	require.NoError(ioutil.WriteFile(python, []byte("#!/bin/sh\necho 'six==1.11.0'\n"), 0755))
+
+	derived, err = inventory.forTool(packageTool{Virtualenv: dir})
+	require.NoError(err)
+	assert.Equal(python, derived.args[0])
+	result, msg, err = derived.installed("six")
+	assert.NoError(err)
+	assert.True(result)
+	assert.Contains(msg, python)
+	result, msg, err = derived.installed("requests")
+	assert.NoError(err)
+	assert.False(result)
+	assert.Contains(msg, python)
+
+	for _, tool := range []packageTool{
+		{Interpreter: "sh", Tool: "pip"},
+		{Virtualenv: dir, Interpreter: "sh"},
+		{Virtualenv: filepath.Join(dir, "missing")},
+	} {
+		_, err = inventory.forTool(tool)
+		assert.Error(err, "%+v", tool)
+	}
+
+	inventory.virtualenv = false
+	inventory.interpreterArgs = nil
+	_, err = inventory.forTool(packageTool{Virtualenv: dir})
+	assert.Error(err)
+	_, err = inventory.forTool(packageTool{Interpreter: "sh"})
+	assert.Error(err)
 }
@@ -9,30 +9,44 @@ import (
 )
 
 func registerPackageGroupChecks() {
-	packageGroupFactoryFactory := func(name string, gr GroupRequirements, checker packageChecker) func() amboy.Job {
+	packageGroupFactoryFactory := func(name string, gr GroupRequirements, inventory *packageInventory) func() amboy.Job {
 		return func() amboy.Job {
 			gr.Name = name
 			return &packageGroup{
 				Base:         NewBase(name, 0),
 				Requirements: gr,
-				checker:      checker,
+				inventory:    inventory,
 			}
 		}
 	}
 
-	for pkg, checker := range packageCheckerRegistry {
+	for pkg, inventory := range packageInventoryRegistry {
 		for group, requirements := range groupRequirementRegistry {
 			name := fmt.Sprintf("%s-group-%s", pkg, group)
-			registry.AddJobType(name, packageGroupFactoryFactory(name, requirements, checker))
+			registry.AddJobType(name, packageGroupFactoryFactory(name, requirements, inventory))
 		}
 	}
 }
 
+// packageGroup checks a group of packages. Versions maps package
+// names to version range expressions: packages that are installed but
+// do not satisfy their version constraint count as missing. Groups may
+// specify an interpreter, tool, or virtualenv to use in place of the
+// package manager on the PATH. Groups use the inventory from their
+// run's PackageInventories, if set.
 type packageGroup struct {
-	Packages     []string          `bson:"packages" json:"packages" yaml:"packages"`
-	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
-	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
-	checker      packageChecker
+	Packages      []string          `bson:"packages" json:"packages" yaml:"packages"`
+	Versions      map[string]string `bson:"versions" json:"versions" yaml:"versions"`
+	VersionScheme string            `bson:"versionScheme" json:"versionScheme" yaml:"versionScheme"`
+	Requirements  GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	packageTool   `bson:",inline" json:",inline" yaml:",inline"`
+	*Base         `bson:"metadata" json:"metadata" yaml:"metadata"`
+	inventory     *packageInventory
+	inventories   *PackageInventories
+}
+
+func (c *packageGroup) setPackageInventories(set *PackageInventories) {
+	c.inventories = set
 }
 
 func (c *packageGroup) Run() {
@@ -52,12 +66,42 @@ func (c *packageGroup) Run() {
 		return
 	}
 
+	inventory, err := c.inventories.get(c.inventory).forTool(c.packageTool)
+	if err != nil {
+		c.setState(false)
+		c.setMessage(err.Error())
+		c.AddError(errors.Wrapf(err, "problem resolving package tool for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
 	var installed []string
 	var missing []string
 	var messages []string
 
 	for _, pkg := range c.Packages {
-		exists, msg := c.checker(pkg)
+		exists, msg, err := inventory.installed(pkg)
+		if err != nil {
+			c.setState(false)
+			c.setMessage(err.Error())
+			c.AddError(errors.Wrapf(err, "problem checking packages for '%s' (%s) check",
+				c.ID(), c.Name()))
+			return
+		}
+
+		if expr, ok := c.Versions[pkg]; exists && ok {
+			result, versionMsg, err := inventory.checkVersion(pkg, versionConstraint{
+				Scheme: c.VersionScheme,
+				Range:  expr,
+			})
+			if err != nil {
+				versionMsg = err.Error()
+			}
+
+			exists = result
+			msg = versionMsg
+		}
+
 		if exists {
 			installed = append(installed, pkg)
 		} else {
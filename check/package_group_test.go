@@ -24,10 +24,15 @@ func (s *PackageGroupSuite) SetupSuite() {
 }
 
 func (s *PackageGroupSuite) SetupTest() {
+	inventory := &packageInventory{
+		args:  []string{"echo", "foo 1.0\nbar 1.0\nbaz 1.0"},
+		parse: parseFieldsInventory,
+	}
+
 	s.check = &packageGroup{
 		Base:         NewBase(s.name, 0),
 		Requirements: GroupRequirements{Name: s.name, All: true},
-		checker:      packageCheckerFactory([]string{"echo", "PASS"}),
+		inventory:    inventory,
 	}
 }
 
@@ -59,7 +64,10 @@ func (s *PackageGroupSuite) TestFailsWithNoneRequirements() {
 }
 
 func (s *PackageGroupSuite) TestFailedCheckerIdentifiesMissingFunctions() {
-	s.check.checker = packageCheckerFactory([]string{"python", "-c", "exit(1)"})
+	s.check.inventory = &packageInventory{
+		args:  []string{"false"},
+		parse: parseFieldsInventory,
+	}
 
 	s.check.Packages = []string{"foo", "bar", "baz"}
 
@@ -67,3 +75,47 @@ func (s *PackageGroupSuite) TestFailedCheckerIdentifiesMissingFunctions() {
 	s.Error(s.check.Error())
 	s.False(s.check.Output().Passed)
 }
+
+func (s *PackageGroupSuite) TestVersionConstraints() {
+	s.check.inventory = &packageInventory{
+		args:   []string{"echo", "glibc 2.17\nopenssl 1.0.2k"},
+		parse:  parseFieldsInventory,
+		scheme: "loose",
+	}
+	s.check.Packages = []string{"glibc", "openssl"}
+	s.check.Versions = map[string]string{"glibc": ">=2.17"}
+
+	s.check.Run()
+	s.NoError(s.check.Error())
+	s.True(s.check.Output().Passed)
+
+	s.SetupTest()
+	s.check.inventory = &packageInventory{
+		args:   []string{"echo", "glibc 2.12\nopenssl 1.0.2k"},
+		parse:  parseFieldsInventory,
+		scheme: "loose",
+	}
+	s.check.Packages = []string{"glibc", "openssl"}
+	s.check.Versions = map[string]string{"glibc": ">=2.17"}
+
+	s.check.Run()
+	s.Error(s.check.Error())
+	output := s.check.Output()
+	s.False(output.Passed)
+	s.Contains(output.Message, "package 'glibc' version is '2.12'")
+}
+
+func (s *PackageGroupSuite) TestInventoryErrorsFailNoneRequirements() {
+	s.check.inventory = &packageInventory{
+		args:  []string{"false"},
+		parse: parseFieldsInventory,
+	}
+	s.check.Requirements = GroupRequirements{Name: s.check.Name(), None: true}
+	s.check.Packages = []string{"foo", "bar", "baz"}
+
+	s.check.Run()
+	s.Error(s.check.Error())
+	output := s.check.Output()
+	s.False(output.Passed)
+	s.Contains(output.Message, "problem listing installed packages")
+}
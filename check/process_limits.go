@@ -0,0 +1,232 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "process-limits"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &processRlimitCheck{
+			Base:     NewBase(name, 0),
+			procRoot: "/proc",
+		}
+	})
+}
+
+// procLimitNames maps the rows of /proc/<pid>/limits to the names of
+// the limit checks.
+var procLimitNames = map[string]string{
+	"Max cpu time":          "cpu-time",
+	"Max file size":         "file-size",
+	"Max data size":         "data-size",
+	"Max stack size":        "stack-size",
+	"Max core file size":    "core-size",
+	"Max resident set":      "resident-set-size",
+	"Max processes":         "max-processes",
+	"Max open files":        "open-files",
+	"Max locked memory":     "locked-memory",
+	"Max address space":     "address-size",
+	"Max msgqueue size":     "message-queue-size",
+	"Max nice priority":     "nice-limit",
+	"Max realtime priority": "realtime-priority",
+}
+
+// limitValues are the soft and hard values of a limit.
+type limitValues struct {
+	soft uint64
+	hard uint64
+}
+
+// parseProcLimits parses the contents of /proc/<pid>/limits, which is
+// a table with fixed width columns.
+func parseProcLimits(fn string) (map[string]limitValues, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening '%s'", fn)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		return nil, errors.Errorf("'%s' is empty", fn)
+	}
+
+	header := scanner.Text()
+	softCol := strings.Index(header, "Soft Limit")
+	hardCol := strings.Index(header, "Hard Limit")
+	unitsCol := strings.Index(header, "Units")
+	if softCol < 0 || hardCol < softCol || unitsCol < hardCol {
+		return nil, errors.Errorf("'%s' does not have the expected columns", fn)
+	}
+
+	out := map[string]limitValues{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) < unitsCol {
+			line += strings.Repeat(" ", unitsCol-len(line))
+		}
+
+		name, ok := procLimitNames[strings.TrimSpace(line[:softCol])]
+		if !ok {
+			continue
+		}
+
+		var values [2]uint64
+		for idx, field := range []string{line[softCol:hardCol], line[hardCol:unitsCol]} {
+			value, err := parseLimitValue(field)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing %s limit in '%s'", name, fn)
+			}
+			values[idx] = value
+		}
+
+		out[name] = limitValues{soft: values[0], hard: values[1]}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "problem reading '%s'", fn)
+	}
+
+	return out, nil
+}
+
+// limitExpectation holds the minimum soft and hard values of a limit,
+// with the same semantics as the limit checks.
+type limitExpectation struct {
+	Soft string `bson:"soft" json:"soft" yaml:"soft"`
+	Hard string `bson:"hard" json:"hard" yaml:"hard"`
+}
+
+// processRlimitCheck checks the resource limits of running processes,
+// which may differ from the limits of greenbay itself. Limits maps the
+// names of limit checks (e.g. "open-files" or "max-processes") to the
+// expected values. Every process that matches the selector must
+// satisfy all of the limits, and the check fails if no process
+// matches. This check requires /proc/<pid>/limits, which is only
+// available on linux.
+type processRlimitCheck struct {
+	processSelector `bson:",inline" json:",inline" yaml:",inline"`
+	Limits          map[string]limitExpectation `bson:"limits" json:"limits" yaml:"limits"`
+	*Base           `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	procRoot string
+}
+
+func (c *processRlimitCheck) validate() error {
+	catcher := grip.NewCatcher()
+	catcher.Add(c.processSelector.validate())
+
+	if len(c.Limits) == 0 {
+		catcher.Add(errors.New("no limits specified"))
+	}
+
+	for name, expected := range c.Limits {
+		if !limitNameIsKnown(name) {
+			catcher.Add(errors.Errorf("'%s' is not a known limit", name))
+		}
+
+		if expected.Soft == "" && expected.Hard == "" {
+			catcher.Add(errors.Errorf("no soft or hard value specified for '%s' limit", name))
+		}
+
+		for _, value := range []string{expected.Soft, expected.Hard} {
+			if value == "" {
+				continue
+			}
+
+			if _, err := parseLimitValue(value); err != nil {
+				catcher.Add(errors.Wrapf(err, "invalid value for '%s' limit", name))
+			}
+		}
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "invalid '%s' (%s) check", c.ID(), c.Name())
+}
+
+func limitNameIsKnown(name string) bool {
+	for _, known := range procLimitNames {
+		if known == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *processRlimitCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	procs, err := c.processSelector.find(c.procRoot)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if len(procs) == 0 {
+		c.setState(false)
+		c.AddError(errors.Errorf("no process matches %s", c.processSelector))
+		return
+	}
+
+	names := make([]string, 0, len(c.Limits))
+	for name := range c.Limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var messages []string
+	catcher := grip.NewCatcher()
+	for _, proc := range procs {
+		limits, err := parseProcLimits(filepath.Join(c.procRoot, strconv.Itoa(proc.pid), "limits"))
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem reading limits of process %s", proc))
+			continue
+		}
+
+		for _, name := range names {
+			actual, ok := limits[name]
+			if !ok {
+				catcher.Add(errors.Errorf("process %s does not report a %s limit", proc, name))
+				continue
+			}
+
+			messages = append(messages, fmt.Sprintf("process %s %s limit: soft=%s hard=%s", proc, name,
+				formatLimitValue(actual.soft), formatLimitValue(actual.hard)))
+
+			expected := c.Limits[name]
+			catcher.Add(errors.Wrapf(compareResourceLimit(name, expected.Soft, expected.Hard, actual.soft, actual.hard),
+				"process %s", proc))
+		}
+	}
+
+	c.setMessage(messages)
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
@@ -0,0 +1,222 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/mongodb/amboy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProcLimits = `Limit                     Soft Limit           Hard Limit           Units     
+Max cpu time              unlimited            unlimited            seconds   
+Max file size             unlimited            unlimited            bytes     
+Max stack size            8388608              unlimited            bytes     
+Max core file size        0                    unlimited            bytes     
+Max processes             %d                   63448                processes 
+Max open files            %d                   64000                files     
+Max file locks            unlimited            unlimited            locks     
+Max nice priority         0                    0                    
+Max realtime priority     0                    0                    
+`
+
+// writeTestProcess adds a process to a fake proc filesystem.
+func writeTestProcess(t *testing.T, root string, pid int, comm, cmdline, limits string) {
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644))
+	if limits != "" {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "limits"), []byte(limits), 0644))
+	}
+}
+
+func TestParseProcLimits(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-proc-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "limits")
+	require.NoError(ioutil.WriteFile(fn, []byte(fmt.Sprintf(testProcLimits, 4096, 1024)), 0644))
+
+	limits, err := parseProcLimits(fn)
+	require.NoError(err)
+	assert.Equal(limitValues{soft: 1024, hard: 64000}, limits["open-files"])
+	assert.Equal(limitValues{soft: 4096, hard: 63448}, limits["max-processes"])
+	assert.Equal(limitValues{soft: 0, hard: rlimitUnlimited}, limits["core-size"])
+	assert.Equal(limitValues{soft: rlimitUnlimited, hard: rlimitUnlimited}, limits["cpu-time"])
+	assert.Equal(limitValues{}, limits["nice-limit"])
+	assert.NotContains(limits, "Max file locks")
+
+	require.NoError(ioutil.WriteFile(fn, []byte("not a limits file\n"), 0644))
+	_, err = parseProcLimits(fn)
+	assert.Error(err)
+
+	_, err = parseProcLimits(filepath.Join(dir, "missing"))
+	assert.Error(err)
+}
+
+func TestProcessSelector(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	root, err := ioutil.TempDir("", "greenbay-proc-")
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	writeTestProcess(t, root, 10, "mongod", "/usr/bin/mongod\x00--config\x00/etc/mongod.conf\x00", "")
+	writeTestProcess(t, root, 20, "mongod", "mongod\x00--port\x0027018\x00", "")
+	writeTestProcess(t, root, 30, "mongodb-mms-aut", "/opt/mongodb-mms-automation/bin/mongodb-mms-automation-agent\x00", "")
+	writeTestProcess(t, root, 40, "bash", "bash\x00", "")
+
+	pidFile := filepath.Join(root, "mongod.pid")
+	require.NoError(ioutil.WriteFile(pidFile, []byte("20\n"), 0644))
+
+	cases := map[string]struct {
+		selector processSelector
+		pids     []int
+	}{
+		"pid":           {processSelector{PID: 10}, []int{10}},
+		"missing pid":   {processSelector{PID: 50}, nil},
+		"pid file":      {processSelector{PIDFile: pidFile}, []int{20}},
+		"executable":    {processSelector{Executable: "mongod"}, []int{10, 20}},
+		"truncated":     {processSelector{Executable: "mongodb-mms-automation-agent"}, []int{30}},
+		"cmdline":       {processSelector{Cmdline: "--port 2701[78]"}, []int{20}},
+		"no match":      {processSelector{Executable: "mongos"}, nil},
+		"cmdline regex": {processSelector{Cmdline: "^(bash|/usr/bin/mongod )"}, []int{10, 40}},
+	}
+
+	for name, test := range cases {
+		require.NoError(test.selector.validate(), name)
+		procs, err := test.selector.find(root)
+		require.NoError(err, name)
+
+		var pids []int
+		for _, proc := range procs {
+			pids = append(pids, proc.pid)
+		}
+		assert.Equal(test.pids, pids, name)
+	}
+
+	for _, selector := range []processSelector{
+		{},
+		{PID: 10, Executable: "mongod"},
+		{PID: -1},
+		{Cmdline: "("},
+	} {
+		assert.Error(selector.validate())
+	}
+
+	_, err = processSelector{PIDFile: filepath.Join(root, "missing.pid")}.find(root)
+	assert.Error(err)
+}
+
+func TestProcessRlimitCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	root, err := ioutil.TempDir("", "greenbay-proc-")
+	require.NoError(err)
+	defer os.RemoveAll(root)
+
+	writeTestProcess(t, root, 10, "mongod", "mongod\x00", fmt.Sprintf(testProcLimits, 64000, 64000))
+	writeTestProcess(t, root, 20, "mongod", "mongod\x00", fmt.Sprintf(testProcLimits, 64000, 1024))
+	writeTestProcess(t, root, 30, "mongos", "mongos\x00", fmt.Sprintf(testProcLimits, 64000, 64000))
+
+	check := &processRlimitCheck{
+		processSelector: processSelector{Executable: "mongos"},
+		Limits: map[string]limitExpectation{
+			"open-files": {Soft: "64000", Hard: "64000"},
+			"core-size":  {Hard: "unlimited"},
+		},
+		Base:     NewBase("process-limits", 0),
+		procRoot: root,
+	}
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, output.Error)
+	assert.Contains(output.Message, "process 30 (mongos) open-files limit: soft=64000 hard=64000")
+	assert.Contains(output.Message, "process 30 (mongos) core-size limit: soft=0 hard=unlimited")
+
+	// every matching process must satisfy the limits.
+	check = &processRlimitCheck{
+		processSelector: processSelector{Executable: "mongod"},
+		Limits:          map[string]limitExpectation{"open-files": {Soft: "64000"}},
+		Base:            NewBase("process-limits", 0),
+		procRoot:        root,
+	}
+	check.Run()
+	output = check.Output()
+	assert.False(output.Passed)
+	require.Error(check.Error())
+	assert.Contains(check.Error().Error(), "soft open-files limit is 1024, expected at least 64000")
+	assert.Contains(check.Error().Error(), "process 20 (mongod)")
+	assert.NotContains(check.Error().Error(), "process 10")
+	assert.Contains(output.Message, "process 10 (mongod) open-files limit: soft=64000 hard=64000")
+
+	for _, check := range []*processRlimitCheck{
+		{processSelector: processSelector{Executable: "mongodump"}, Limits: map[string]limitExpectation{"max-processes": {Soft: "1"}}},
+		{processSelector: processSelector{PID: 10}, Limits: map[string]limitExpectation{"file-locks": {Soft: "1"}}},
+		{processSelector: processSelector{PID: 10}, Limits: map[string]limitExpectation{"max-processes": {}}},
+		{processSelector: processSelector{PID: 10}, Limits: map[string]limitExpectation{"resident-set-size": {Soft: "1"}}},
+		{processSelector: processSelector{PID: 10}},
+	} {
+		check.Base = NewBase("process-limits", 0)
+		check.procRoot = root
+		check.Run()
+		assert.False(check.Output().Passed)
+		assert.Error(check.Error())
+	}
+}
+
+func TestProcessRlimitCheckRunningProcess(t *testing.T) {
+	if _, err := os.Stat("/proc/self/limits"); err != nil {
+		t.Skip("test requires /proc/<pid>/limits")
+	}
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(cmd.Start())
+	defer cmd.Process.Kill()
+
+	check := &processRlimitCheck{
+		processSelector: processSelector{PID: cmd.Process.Pid},
+		Limits:          map[string]limitExpectation{"open-files": {Soft: "1", Hard: "1"}},
+		Base:            NewBase("process-limits", 0),
+		procRoot:        "/proc",
+	}
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, output.Error)
+	assert.Contains(output.Message, fmt.Sprintf("process %d (sleep) open-files limit", cmd.Process.Pid))
+}
+
+func TestProcessRlimitCheckSerialization(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	selector := processSelector{PIDFile: "/var/run/mongod.pid", Executable: "mongod"}
+	limits := map[string]limitExpectation{"open-files": {Soft: "64000", Hard: "64000"}}
+
+	for _, format := range []amboy.Format{amboy.BSON, amboy.JSON, amboy.YAML} {
+		check := &processRlimitCheck{processSelector: selector, Limits: limits, Base: NewBase("process-limits", 0)}
+		data, err := amboy.ConvertTo(format, check)
+		require.NoError(err)
+
+		out := &processRlimitCheck{Base: NewBase("process-limits", 0)}
+		require.NoError(amboy.ConvertFrom(format, data, out))
+		assert.Equal(selector, out.processSelector, "format %d", format)
+		assert.Equal(limits, out.Limits, "format %d", format)
+	}
+}
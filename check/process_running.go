@@ -0,0 +1,300 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	processRunningFactoryFactory := func(name string, running bool) func() amboy.Job {
+		return func() amboy.Job {
+			return &processRunningCheck{
+				Base:     NewBase(name, 0),
+				running:  running,
+				procRoot: "/proc",
+			}
+		}
+	}
+
+	registry.AddJobType("process-running", processRunningFactoryFactory("process-running", true))
+	registry.AddJobType("process-not-running", processRunningFactoryFactory("process-not-running", false))
+}
+
+// the kernel reports process start times in clock ticks, which are
+// USER_HZ, or 100 per second, on all platforms that greenbay supports.
+const procClockTicks = 100
+
+// processRunningCheck checks that processes that match the selector
+// are (or are not) running. User is the name or uid of the user that
+// the processes must run as (the effective uid). For running
+// processes, MinInstances and MaxInstances bound the number of
+// matching processes, where a MinInstances value of 0 requires one
+// process and a MaxInstances value of 0 means that there is no upper
+// bound, and MinUptime is the minimum time that every process has been
+// running (e.g. "5m"). For processes that are not running, User limits
+// the check to processes that run as the user (e.g. "no mongod runs
+// as root"). The message lists every matching process. This check
+// requires the proc filesystem, which is only available on linux.
+type processRunningCheck struct {
+	processSelector `bson:",inline" json:",inline" yaml:",inline"`
+	User            string `bson:"user" json:"user" yaml:"user"`
+	MinInstances    int    `bson:"min_instances" json:"min_instances" yaml:"min_instances"`
+	MaxInstances    int    `bson:"max_instances" json:"max_instances" yaml:"max_instances"`
+	MinUptime       string `bson:"min_uptime" json:"min_uptime" yaml:"min_uptime"`
+	*Base           `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	running  bool
+	procRoot string
+}
+
+// processStatus describes the owner and uptime of a process.
+type processStatus struct {
+	*processInfo
+	uid    uint32
+	uptime time.Duration
+}
+
+func (p *processStatus) String() string {
+	return fmt.Sprintf("%s user=%s uptime=%s: %s", p.processInfo, describeUser(p.uid), p.uptime, p.cmdline)
+}
+
+// readProcessUID returns the effective uid of a process, from
+// /proc/<pid>/status.
+func readProcessUID(procRoot string, pid int) (uint32, error) {
+	fn := filepath.Join(procRoot, strconv.Itoa(pid), "status")
+	f, err := os.Open(fn)
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem opening '%s'", fn)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// the uid line has the real, effective, saved, and
+		// filesystem uids.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "Uid:" {
+			continue
+		}
+
+		uid, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return 0, errors.Wrapf(err, "problem parsing uid in '%s'", fn)
+		}
+
+		return uint32(uid), nil
+	}
+
+	if err = scanner.Err(); err != nil {
+		return 0, errors.Wrapf(err, "problem reading '%s'", fn)
+	}
+
+	return 0, errors.Errorf("'%s' does not report a uid", fn)
+}
+
+// readProcessUptime returns the time since a process started, using
+// the start time in /proc/<pid>/stat and the system uptime.
+func readProcessUptime(procRoot string, pid int) (time.Duration, error) {
+	fn := filepath.Join(procRoot, strconv.Itoa(pid), "stat")
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem reading '%s'", fn)
+	}
+
+	// the process name, in parentheses, may contain spaces, so the
+	// fields start after the last parenthesis, with the state, which
+	// is the third field. The start time is the 22nd field.
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 {
+		return 0, errors.Errorf("'%s' does not have a start time", fn)
+	}
+
+	start, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem parsing start time in '%s'", fn)
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(procRoot, "uptime"))
+	if err != nil {
+		return 0, errors.Wrap(err, "problem reading system uptime")
+	}
+
+	fields = strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, errors.New("system uptime is empty")
+	}
+
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "problem parsing system uptime")
+	}
+
+	out := time.Duration(uptime*float64(time.Second)) - time.Duration(start)*time.Second/procClockTicks
+	if out < 0 {
+		out = 0
+	}
+
+	return out.Round(time.Second), nil
+}
+
+func (c *processRunningCheck) validate() error {
+	catcher := grip.NewCatcher()
+	catcher.Add(c.processSelector.validate())
+
+	if c.MinInstances < 0 || c.MaxInstances < 0 {
+		catcher.Add(errors.Errorf("instance counts cannot be negative [min=%d, max=%d]",
+			c.MinInstances, c.MaxInstances))
+	}
+
+	if c.MaxInstances > 0 && c.MinInstances > c.MaxInstances {
+		catcher.Add(errors.Errorf("minimum instance count %d is larger than maximum %d",
+			c.MinInstances, c.MaxInstances))
+	}
+
+	if c.MinUptime != "" {
+		if uptime, err := time.ParseDuration(c.MinUptime); err != nil || uptime < 0 {
+			catcher.Add(errors.Errorf("minimum uptime '%s' is not a valid duration", c.MinUptime))
+		}
+	}
+
+	if !c.running && (c.MinInstances != 0 || c.MaxInstances != 0 || c.MinUptime != "") {
+		catcher.Add(errors.New("instance counts and uptime only apply to running processes"))
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "invalid '%s' (%s) check", c.ID(), c.Name())
+}
+
+// status reads the owner and uptime of the processes. Processes that
+// exit while greenbay reads them are skipped.
+func (c *processRunningCheck) status(procs []*processInfo) ([]*processStatus, error) {
+	var out []*processStatus
+	for _, proc := range procs {
+		uid, err := readProcessUID(c.procRoot, proc.pid)
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		uptime, err := readProcessUptime(c.procRoot, proc.pid)
+		if os.IsNotExist(errors.Cause(err)) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		out = append(out, &processStatus{processInfo: proc, uid: uid, uptime: uptime})
+	}
+
+	return out, nil
+}
+
+func (c *processRunningCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var uid uint32
+	if c.User != "" {
+		var err error
+		uid, err = resolveUserID(c.User)
+		if err != nil {
+			c.setState(false)
+			c.AddError(err)
+			return
+		}
+	}
+
+	found, err := c.processSelector.find(c.procRoot)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	procs, err := c.status(found)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var messages []string
+	for _, proc := range procs {
+		messages = append(messages, proc.String())
+	}
+	if len(messages) == 0 {
+		messages = append(messages, fmt.Sprintf("no process matches %s", c.processSelector))
+	}
+	c.setMessage(messages)
+
+	catcher := grip.NewCatcher()
+	if c.running {
+		c.checkRunning(catcher, procs, uid)
+	} else {
+		for _, proc := range procs {
+			if c.User == "" || proc.uid == uid {
+				catcher.Add(errors.Errorf("process %s matches %s", proc.processInfo, c.processSelector))
+			}
+		}
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+func (c *processRunningCheck) checkRunning(catcher grip.Catcher, procs []*processStatus, uid uint32) {
+	minimum := c.MinInstances
+	if minimum == 0 {
+		minimum = 1
+	}
+
+	if len(procs) < minimum {
+		catcher.Add(errors.Errorf("%d process(es) match %s, fewer than the minimum of %d",
+			len(procs), c.processSelector, minimum))
+	}
+
+	if c.MaxInstances > 0 && len(procs) > c.MaxInstances {
+		catcher.Add(errors.Errorf("%d process(es) match %s, more than the maximum of %d",
+			len(procs), c.processSelector, c.MaxInstances))
+	}
+
+	var minUptime time.Duration
+	if c.MinUptime != "" {
+		minUptime, _ = time.ParseDuration(c.MinUptime)
+	}
+
+	for _, proc := range procs {
+		if c.User != "" && proc.uid != uid {
+			catcher.Add(errors.Errorf("process %s runs as user %s, expected %s",
+				proc.processInfo, describeUser(proc.uid), describeUser(uid)))
+		}
+
+		if proc.uptime < minUptime {
+			catcher.Add(errors.Errorf("process %s has been running for %s, less than the minimum of %s",
+				proc.processInfo, proc.uptime, c.MinUptime))
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestProcessStatus adds the owner and start time of a process
+// to a fake proc tree, where the system has been up for 1000 seconds.
+func writeTestProcessStatus(t *testing.T, root string, pid int, comm string, uid int, started time.Duration) {
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "status"), []byte(fmt.Sprintf(
+		"Name:\t%s\nState:\tS (sleeping)\nUid:\t%d\t%d\t%d\t%d\nGid:\t0\t0\t0\t0\n", comm, uid, uid, uid, uid)), 0644))
+
+	// the start time is the 22nd field, in clock ticks after boot.
+	stat := fmt.Sprintf("%d (%s) S 1 %d %d 0 -1 4194560 100 0 0 0 10 5 0 0 20 0 4 0 %d 1000000 100",
+		pid, comm, pid, pid, int64(started/time.Second)*procClockTicks)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "stat"), []byte(stat+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "uptime"), []byte("1000.50 3900.20\n"), 0644))
+}
+
+func TestReadProcessUptime(t *testing.T) {
+	assert := assert.New(t)
+	root, err := ioutil.TempDir("", "greenbay-proc-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	writeTestProcess(t, root, 10, "my (odd) name", "odd\x00", "")
+	writeTestProcessStatus(t, root, 10, "my (odd) name", 0, 400*time.Second)
+
+	uptime, err := readProcessUptime(root, 10)
+	assert.NoError(err)
+	assert.Equal(601*time.Second, uptime)
+
+	uid, err := readProcessUID(root, 10)
+	assert.NoError(err)
+	assert.Equal(uint32(0), uid)
+
+	_, err = readProcessUptime(root, 20)
+	assert.Error(err)
+	_, err = readProcessUID(root, 20)
+	assert.Error(err)
+}
+
+func TestProcessSelectorExecutablePath(t *testing.T) {
+	assert := assert.New(t)
+	root, err := ioutil.TempDir("", "greenbay-proc-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	writeTestProcess(t, root, 10, "mongod", "/usr/bin/mongod\x00--config\x00/etc/mongod.conf\x00", "")
+	writeTestProcess(t, root, 20, "mongod", "mongod\x00", "")
+	require.NoError(t, os.Symlink("/opt/mongodb/bin/mongod (deleted)", filepath.Join(root, "20", "exe")))
+
+	for path, expected := range map[string][]int{
+		"/usr/bin/mongod":         {10},
+		"/opt/mongodb/bin/mongod": {20},
+		"/usr/local/bin/mongod":   nil,
+	} {
+		procs, err := processSelector{Executable: path}.find(root)
+		assert.NoError(err, path)
+
+		var pids []int
+		for _, proc := range procs {
+			pids = append(pids, proc.pid)
+		}
+		assert.Equal(expected, pids, path)
+	}
+}
+
+func TestProcessRunningCheck(t *testing.T) {
+	root, err := ioutil.TempDir("", "greenbay-proc-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	// two mongods, running as an unprivileged user, and a mongos
+	// that has just started as root.
+	writeTestProcess(t, root, 10, "mongod", "/usr/bin/mongod\x00--port\x0027017\x00", "")
+	writeTestProcessStatus(t, root, 10, "mongod", 4242, 100*time.Second)
+	writeTestProcess(t, root, 20, "mongod", "/usr/bin/mongod\x00--port\x0027018\x00", "")
+	writeTestProcessStatus(t, root, 20, "mongod", 4242, 200*time.Second)
+	writeTestProcess(t, root, 30, "mongos", "/usr/bin/mongos\x00", "")
+	writeTestProcessStatus(t, root, 30, "mongos", 0, 990*time.Second)
+
+	cases := map[string]struct {
+		name    string
+		check   processRunningCheck
+		passes  bool
+		message string
+	}{
+		"Running":                {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}}, true, ""},
+		"RunningAsUser":          {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, User: "4242"}, true, ""},
+		"RunningAsWrongUser":     {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongos"}, User: "4242"}, false, "runs as user 0"},
+		"NotRunning":             {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongo"}}, false, "0 process(es) match executable 'mongo', fewer than the minimum of 1"},
+		"EnoughInstances":        {"process-running", processRunningCheck{processSelector: processSelector{Cmdline: "--port"}, MinInstances: 2, MaxInstances: 2}, true, ""},
+		"TooFewInstances":        {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, MinInstances: 3}, false, "fewer than the minimum of 3"},
+		"TooManyInstances":       {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, MaxInstances: 1}, false, "more than the maximum of 1"},
+		"LongEnoughUptime":       {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, MinUptime: "10m"}, true, ""},
+		"ShortUptime":            {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongos"}, MinUptime: "1m"}, false, "running for 11s, less than the minimum of 1m"},
+		"ByPath":                 {"process-running", processRunningCheck{processSelector: processSelector{Executable: "/usr/bin/mongos"}}, true, ""},
+		"NotRunningPasses":       {"process-not-running", processRunningCheck{processSelector: processSelector{Executable: "mongo"}}, true, ""},
+		"NotRunningFails":        {"process-not-running", processRunningCheck{processSelector: processSelector{Executable: "mongos"}}, false, "process 30 (mongos) matches executable 'mongos'"},
+		"NotRunningAsRoot":       {"process-not-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, User: "0"}, true, ""},
+		"NotRunningAsRootFails":  {"process-not-running", processRunningCheck{processSelector: processSelector{Cmdline: "mongo"}, User: "0"}, false, "process 30 (mongos)"},
+		"NotRunningWithCounts":   {"process-not-running", processRunningCheck{processSelector: processSelector{Executable: "mongo"}, MaxInstances: 1}, false, "only apply to running processes"},
+		"NoSelector":             {"process-running", processRunningCheck{}, false, "specify exactly one"},
+		"InvalidCounts":          {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, MinInstances: 3, MaxInstances: 2}, false, "larger than maximum"},
+		"InvalidUptime":          {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, MinUptime: "soon"}, false, "not a valid duration"},
+		"UnknownUser":            {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, User: "greenbay-no-such-user"}, false, "problem looking up user"},
+		"RunningListsProcesses":  {"process-running", processRunningCheck{processSelector: processSelector{Executable: "mongod"}, User: "0"}, false, "runs as user 4242"},
+		"NotRunningListsMatches": {"process-not-running", processRunningCheck{processSelector: processSelector{PID: 20}}, false, "process 20 (mongod)"},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			factory, err := registry.GetJobFactory(test.name)
+			require.NoError(t, err)
+
+			check := factory().(*processRunningCheck)
+			base, running := check.Base, check.running
+			*check = test.check
+			check.Base, check.running = base, running
+			check.procRoot = root
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if !test.passes {
+				assert.Contains(t, output.Error, test.message)
+			}
+		})
+	}
+
+	t.Run("MessageListsProcesses", func(t *testing.T) {
+		check := &processRunningCheck{
+			processSelector: processSelector{Executable: "mongod"},
+			User:            "0",
+			Base:            NewBase("process-running", 0),
+			running:         true,
+			procRoot:        root,
+		}
+		check.Run()
+
+		output := check.Output()
+		assert.False(t, output.Passed)
+		assert.Contains(t, output.Message, "10 (mongod) user=4242 uptime=15m1s: /usr/bin/mongod --port 27017")
+		assert.Contains(t, output.Message, "20 (mongod) user=4242 uptime=13m21s: /usr/bin/mongod --port 27018")
+	})
+}
+
+func TestProcessRunningCheckWithSystemProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process checks are only available on linux")
+	}
+
+	check := &processRunningCheck{
+		processSelector: processSelector{PID: 1},
+		MaxInstances:    1,
+		Base:            NewBase("process-running", 0),
+		running:         true,
+		procRoot:        "/proc",
+	}
+	check.Run()
+
+	output := check.Output()
+	assert.True(t, output.Passed, output.Error)
+	assert.Contains(t, output.Message, "1 (")
+}
+
+func TestProcessRunningCheckSerialization(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	selector := processSelector{Executable: "mongod", Cmdline: "--replSet"}
+
+	for _, format := range []amboy.Format{amboy.BSON, amboy.JSON, amboy.YAML} {
+		check := &processRunningCheck{processSelector: selector, User: "mongodb", MinInstances: 2,
+			Base: NewBase("process-running", 0)}
+		data, err := amboy.ConvertTo(format, check)
+		require.NoError(err)
+
+		out := &processRunningCheck{Base: NewBase("process-running", 0)}
+		require.NoError(amboy.ConvertFrom(format, data, out))
+		assert.Equal(selector, out.processSelector, "format %d", format)
+		assert.Equal("mongodb", out.User, "format %d", format)
+		assert.Equal(2, out.MinInstances, "format %d", format)
+	}
+}
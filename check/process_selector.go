@@ -0,0 +1,190 @@
+package check
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// processSelector finds running processes, using the proc
+// filesystem, by one of: PID; PIDFile, a file that contains a pid;
+// Executable, the name of the program, which matches the base name of
+// the first argument or the executable, or the process name in
+// /proc/<pid>/comm, or the path of the program, which matches the
+// first argument or the executable; or Cmdline, a regular expression
+// that matches the command line, with arguments separated by spaces.
+// The greenbay process never matches.
+type processSelector struct {
+	PID        int    `bson:"pid" json:"pid" yaml:"pid"`
+	PIDFile    string `bson:"pid_file" json:"pid_file" yaml:"pid_file"`
+	Executable string `bson:"executable" json:"executable" yaml:"executable"`
+	Cmdline    string `bson:"cmdline" json:"cmdline" yaml:"cmdline"`
+}
+
+// processInfo describes a process that a processSelector found.
+type processInfo struct {
+	pid     int
+	name    string
+	cmdline string
+}
+
+// the kernel truncates process names in /proc/<pid>/comm.
+const procCommLength = 15
+
+func (s processSelector) validate() error {
+	set := 0
+	for _, isSet := range []bool{s.PID != 0, s.PIDFile != "", s.Executable != "", s.Cmdline != ""} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return errors.New("specify exactly one of pid, pid_file, executable, or cmdline")
+	}
+
+	if s.PID < 0 {
+		return errors.Errorf("pid %d is not valid", s.PID)
+	}
+
+	if s.Cmdline != "" {
+		if _, err := regexp.Compile(s.Cmdline); err != nil {
+			return errors.Wrapf(err, "problem compiling cmdline pattern '%s'", s.Cmdline)
+		}
+	}
+
+	return nil
+}
+
+func (s processSelector) String() string {
+	switch {
+	case s.PID != 0:
+		return "pid " + strconv.Itoa(s.PID)
+	case s.PIDFile != "":
+		return "pid file '" + s.PIDFile + "'"
+	case s.Executable != "":
+		return "executable '" + s.Executable + "'"
+	default:
+		return "cmdline matching '" + s.Cmdline + "'"
+	}
+}
+
+// readProcess returns information about a process, and false if the
+// process does not exist (or exited while greenbay read it).
+func readProcess(procRoot string, pid int) (*processInfo, bool) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return nil, false
+	}
+
+	comm, err := ioutil.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return nil, false
+	}
+
+	return &processInfo{
+		pid:     pid,
+		name:    strings.TrimSpace(string(comm)),
+		cmdline: strings.TrimSpace(string(bytes.Replace(cmdline, []byte{0}, []byte{' '}, -1))),
+	}, true
+}
+
+func (p *processInfo) matchesExecutable(procRoot, name string) bool {
+	if strings.Contains(name, "/") {
+		return p.matchesExecutablePath(procRoot, name)
+	}
+
+	if p.name == name || (len(name) > procCommLength && p.name == name[:procCommLength]) {
+		return true
+	}
+
+	if fields := strings.Fields(p.cmdline); len(fields) > 0 && filepath.Base(fields[0]) == name {
+		return true
+	}
+
+	exe, err := os.Readlink(filepath.Join(procRoot, strconv.Itoa(p.pid), "exe"))
+
+	return err == nil && filepath.Base(exe) == name
+}
+
+func (p *processInfo) matchesExecutablePath(procRoot, path string) bool {
+	if fields := strings.Fields(p.cmdline); len(fields) > 0 && fields[0] == path {
+		return true
+	}
+
+	// the kernel marks executables that were replaced or removed
+	// after the process started.
+	exe, err := os.Readlink(filepath.Join(procRoot, strconv.Itoa(p.pid), "exe"))
+
+	return err == nil && strings.TrimSuffix(exe, " (deleted)") == path
+}
+
+// find returns the processes that match the selector, ordered by
+// pid. Selectors that do not match any process return an empty list.
+func (s processSelector) find(procRoot string) ([]*processInfo, error) {
+	pid := s.PID
+	if s.PIDFile != "" {
+		data, err := ioutil.ReadFile(s.PIDFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem reading pid file '%s'", s.PIDFile)
+		}
+
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			return nil, errors.Errorf("pid file '%s' does not contain a pid", s.PIDFile)
+		}
+	}
+
+	if pid != 0 {
+		if proc, ok := readProcess(procRoot, pid); ok {
+			return []*processInfo{proc}, nil
+		}
+		return nil, nil
+	}
+
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem listing processes in '%s'", procRoot)
+	}
+
+	var pattern *regexp.Regexp
+	if s.Cmdline != "" {
+		pattern = regexp.MustCompile(s.Cmdline)
+	}
+
+	self := os.Getpid()
+	var out []*processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self || !entry.IsDir() {
+			continue
+		}
+
+		proc, ok := readProcess(procRoot, pid)
+		if !ok {
+			continue
+		}
+
+		if (pattern != nil && pattern.MatchString(proc.cmdline)) ||
+			(pattern == nil && proc.matchesExecutable(procRoot, s.Executable)) {
+			out = append(out, proc)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].pid < out[j].pid })
+
+	return out, nil
+}
+
+func (p *processInfo) String() string {
+	return strconv.Itoa(p.pid) + " (" + p.name + ")"
+}
@@ -31,8 +31,11 @@ func registerProgramChecks() {
 }
 
 type programOutputCheck struct {
-	Source         string `bson:"source" json:"source" yaml:"source"`
-	ExpectedOutput string `bson:"output" json:"output" yaml:"output"`
+	Source         string         `bson:"source" json:"source" yaml:"source"`
+	ExpectedOutput string         `bson:"output" json:"output" yaml:"output"`
+	Timeout        string         `bson:"timeout" json:"timeout" yaml:"timeout"`
+	RunAs          *runAsSpec     `bson:"run_as" json:"run_as" yaml:"run_as"`
+	Limits         *processLimits `bson:"limits" json:"limits" yaml:"limits"`
 	*Base          `bson:"metadata" json:"metadata" yaml:"metadata"`
 	compiler       compiler
 }
@@ -55,24 +58,33 @@ func (c *programOutputCheck) Run() {
 
 	c.ExpectedOutput = strings.Trim(c.ExpectedOutput, "\r\t\n ")
 
-	output, err := c.compiler.CompileAndRun(c.Source)
+	compiler, options, err := configureCompiler(c.compiler, c.RunAs, c.Limits, c.Timeout)
 	if err != nil {
 		c.setState(false)
+		c.setMessage(err.Error())
 		c.AddError(err)
-		c.setMessage(output)
+		return
+	}
+
+	output, err := compiler.CompileAndRun(c.Source)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		c.setMessage(options.identity.describeOutput(output))
 		return
 	}
 
 	if c.ExpectedOutput != output {
 		c.setState(false)
 		c.AddError(errors.New("expected output does not match actual output"))
-		c.setMessage([]string{
+		c.setMessage(options.identity.describeOutput(strings.Join([]string{
 			"-------------------- EXPECTED --------------------",
 			c.ExpectedOutput,
 			"-------------------- ACTUAL --------------------",
 			output,
-		})
+		}, "\n")))
 		return
 	}
 	c.setState(true)
+	c.setMessage(options.identity.describeOutput(""))
 }
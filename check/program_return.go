@@ -33,7 +33,10 @@ func registerProgramReturnChecks() {
 }
 
 type programReturnCheck struct {
-	Source   string `bson:"source" json:"source" yaml:"source"`
+	Source   string         `bson:"source" json:"source" yaml:"source"`
+	Timeout  string         `bson:"timeout" json:"timeout" yaml:"timeout"`
+	RunAs    *runAsSpec     `bson:"run_as" json:"run_as" yaml:"run_as"`
+	Limits   *processLimits `bson:"limits" json:"limits" yaml:"limits"`
 	*Base    `bson:"metadata" json:"metadata" yaml:"metadata"`
 	compiler compiler
 }
@@ -48,14 +51,21 @@ func (c *programReturnCheck) Run() {
 		return
 	}
 
-	_, err := c.compiler.CompileAndRun(c.Source)
+	compiler, options, err := configureCompiler(c.compiler, c.RunAs, c.Limits, c.Timeout)
+	if err != nil {
+		c.setState(false)
+		c.setMessage(err.Error())
+		c.AddError(err)
+		return
+	}
+
+	_, err = compiler.CompileAndRun(c.Source)
 	if err != nil {
 		c.setState(false)
 		c.AddError(errors.New("program did not exit 0"))
-		c.setMessage([]string{
-			err.Error(),
-		})
+		c.setMessage(options.identity.describeOutput(err.Error()))
 		return
 	}
 	c.setState(true)
+	c.setMessage(options.identity.describeOutput(""))
 }
@@ -0,0 +1,170 @@
+package check
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const defaultVersionPattern = `(\d+\.\d+(?:\.\d+)?)`
+
+func init() {
+	name := "program-version"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &programVersion{
+			Base: NewBase(name, 0),
+		}
+	})
+}
+
+// programVersion runs a program and extracts a version from its
+// output (stdout and stderr) using Pattern. If Pattern has a
+// capturing group, the first group is used as the version, otherwise
+// the entire match is. VersionScheme names the scheme used to parse
+// the extracted and expected versions (see versionSchemes); with the
+// default scheme, partial versions, like "4.8", are normalized before
+// comparison. RunAs, Limits, and Timeout apply to the program as they
+// do for the command checks, and programs without a Timeout are
+// killed after defaultCommandTimeout.
+type programVersion struct {
+	Command         string         `bson:"command" json:"command" yaml:"command"`
+	Args            []string       `bson:"args" json:"args" yaml:"args"`
+	Pattern         string         `bson:"pattern" json:"pattern" yaml:"pattern"`
+	Range           string         `bson:"range" json:"range" yaml:"range"`
+	Version         string         `bson:"version" json:"version" yaml:"version"`
+	Relationship    string         `bson:"relationship" json:"relationship" yaml:"relationship"`
+	MinVersion      string         `bson:"minVersion" json:"minVersion" yaml:"minVersion"`
+	MinRelationship string         `bson:"minRelationship" json:"minRelationship" yaml:"minRelationship"`
+	VersionScheme   string         `bson:"versionScheme" json:"versionScheme" yaml:"versionScheme"`
+	Timeout         string         `bson:"timeout" json:"timeout" yaml:"timeout"`
+	RunAs           *runAsSpec     `bson:"run_as" json:"run_as" yaml:"run_as"`
+	Limits          *processLimits `bson:"limits" json:"limits" yaml:"limits"`
+	*Base           `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *programVersion) validate() error {
+	if c.Command == "" {
+		return errors.Errorf("no command specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Pattern == "" {
+		c.Pattern = defaultVersionPattern
+	}
+
+	if c.Relationship == "" {
+		if c.MinVersion != "" {
+			c.Relationship = "lte"
+		} else {
+			c.Relationship = "gte"
+		}
+		grip.Debugf("no relationship specified, using %s", c.Relationship)
+	}
+
+	if c.MinRelationship == "" {
+		c.MinRelationship = "gte"
+	}
+
+	return errors.Wrapf(c.constraint().validate(), "invalid version constraint for '%s' (%s) check",
+		c.ID(), c.Name())
+}
+
+func (c *programVersion) constraint() versionConstraint {
+	return versionConstraint{
+		Scheme:          c.VersionScheme,
+		Range:           c.Range,
+		Version:         c.Version,
+		Relationship:    c.Relationship,
+		MinVersion:      c.MinVersion,
+		MinRelationship: c.MinRelationship,
+	}
+}
+
+func (c *programVersion) extractVersion(output string) (string, error) {
+	re, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return "", errors.Wrapf(err, "problem compiling version pattern '%s'", c.Pattern)
+	}
+
+	match := re.FindStringSubmatch(output)
+	if match == nil {
+		return "", errors.Errorf("pattern '%s' did not match output of '%s'", c.Pattern, c.Command)
+	}
+
+	if len(match) > 1 {
+		return match[1], nil
+	}
+
+	return match[0], nil
+}
+
+func (c *programVersion) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	options, err := resolveCommandOptions(c.RunAs, c.Limits, c.Timeout)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem configuring '%s'", c.Command))
+		c.setMessage(err.Error())
+		return
+	}
+
+	out, err := options.withDefaultLimits().combinedOutput(exec.Command(c.Command, c.Args...))
+	output := strings.Trim(string(out), "\r\t\n ")
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem running '%s'", c.Command))
+		c.setMessage(options.identity.describeOutput(output))
+		return
+	}
+
+	raw, err := c.extractVersion(output)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		c.setMessage(options.identity.describeOutput(output))
+		return
+	}
+
+	actual, normalized, err := parseSchemeVersion(c.VersionScheme, raw)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		c.setMessage(options.identity.describeOutput(output))
+		return
+	}
+
+	result, err := c.constraint().satisfiedBy(actual)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	msg := fmt.Sprintf("'%s' version is %s (%s), expected %s",
+		c.Command, normalized, raw, c.constraint())
+	grip.Debug(msg)
+
+	if !result {
+		c.setState(false)
+		c.setMessage(msg)
+		c.AddError(errors.Errorf("check failed: %s", msg))
+		return
+	}
+
+	c.setMessage(options.identity.describeOutput(""))
+	c.setState(true)
+}
@@ -0,0 +1,96 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgramVersionCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	gccOutput := []string{"gcc (GCC) 4.8.5 20150623 (Red Hat 4.8.5-11)"}
+	partialOutput := []string{"tool version 4.8"}
+
+	cases := []struct {
+		check  *programVersion
+		passes bool
+	}{
+		{&programVersion{Version: "4.8.0"}, false},
+		{&programVersion{Command: "echo", Args: gccOutput}, false},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "4.8", Relationship: "ne"}, false},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "4.8"}, true},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "4.8.5", Relationship: "eq"}, true},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "5.0", Relationship: "gte"}, false},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "5", MinVersion: "4.8"}, true},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "5", MinVersion: "4.9"}, false},
+		{&programVersion{Command: "echo", Args: partialOutput, Version: "4.8", Relationship: "eq"}, true},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "4", Pattern: `\(GCC\) (\d+)`, Relationship: "eq"}, true},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "4", Pattern: `clang (\d+)`}, false},
+		{&programVersion{Command: "echo", Args: gccOutput, Version: "4", Pattern: `(`}, false},
+		{&programVersion{Command: "command-does-not-exist", Version: "1.0"}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Base = NewBase("program-version", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+		if c.passes {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+}
+
+func TestProgramVersionRangeCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	for expr, passes := range map[string]bool{
+		">=4.8 <5":            true,
+		"<4.8 || >=5.0":       false,
+		">=2.7 <3 || >=4.8.5": true,
+		"not-a-range":         false,
+	} {
+		check := &programVersion{
+			Command: "echo",
+			Args:    []string{"gcc (GCC) 4.8.5"},
+			Range:   expr,
+			Base:    NewBase("program-version", 0),
+		}
+		check.Run()
+		output := check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(passes, output.Passed, "%s: %s", expr, output.Message)
+	}
+}
+
+func TestProgramVersionSchemes(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		check  *programVersion
+		passes bool
+	}{
+		{&programVersion{Args: []string{"tool 2.0.0rc1"}, Pattern: `tool (\S+)`, VersionScheme: "pep440", Range: ">=1.11 <2.0"}, true},
+		{&programVersion{Args: []string{"tool 2.0.0rc1"}, Pattern: `tool (\S+)`, VersionScheme: "pep440", Version: "2.0"}, false},
+		{&programVersion{Args: []string{"tool 2.0.0rc1"}, Pattern: `tool (\S+)`, Range: ">=1.11 <2.0"}, false},
+		{&programVersion{Args: []string{"tool 3.2.1-custom"}, Pattern: `tool (\S+)`, VersionScheme: "loose", Version: "3.2.1", Relationship: "lt"}, true},
+		{&programVersion{Args: []string{"tool 1:1.1.1f-1ubuntu2"}, Pattern: `tool (\S+)`, VersionScheme: "dpkg", Version: "1.2.0"}, true},
+		{&programVersion{Args: []string{"tool 4.8"}, VersionScheme: "not-a-scheme", Version: "4.8"}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Command = "echo"
+		c.check.Base = NewBase("program-version", 0)
+		c.check.Run()
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.passes, output.Passed, "%d: %s", idx, output.Message)
+	}
+}
@@ -26,11 +26,13 @@ func init() {
 type pythonModuleVersion struct {
 	Module            string `bson:"module" json:"module" yaml:"module"`
 	Statement         string `bson:"statement" json:"statement" yaml:"statement"`
+	Range             string `bson:"range" json:"range" yaml:"range"`
 	Version           string `bson:"version" json:"version" yaml:"version"`
 	MinVersion        string `bson:"minVersion" json:"minVersion" yaml:"minVersion"`
 	MinRelationship   string `bson:"minRelationship" json:"minRelationship" yaml:"minRelationship"`
 	PythonInterpreter string `bson:"python" json:"python" yaml:"python"`
 	Relationship      string `bson:"relationship" json:"relationship" yaml:"relationship"`
+	VersionScheme     string `bson:"versionScheme" json:"versionScheme" yaml:"versionScheme"`
 	*Base             `bson:"metadata" json:"metadata" yaml:"metadata"`
 }
 
@@ -53,6 +55,8 @@ func (c *pythonModuleVersion) validate() error {
 		grip.Debugf("no relationship specified, using %s", c.Relationship)
 	case "gte", "lte", "lt", "gt", "eq":
 		grip.Debugln("relationship for '%s' check set to '%s'", c.ID(), c.Relationship)
+	default:
+		return validateRelationship(c.Relationship)
 	}
 
 	switch c.MinRelationship {
@@ -61,9 +65,34 @@ func (c *pythonModuleVersion) validate() error {
 		c.MinRelationship = "gte"
 	case "gte", "lte", "lt", "gt", "eq":
 		grip.Debugln("relationship for '%s' check set to '%s'", c.ID(), c.MinRelationship)
+	default:
+		return validateRelationship(c.MinRelationship)
 	}
 
-	return nil
+	return validateVersionScheme(c.VersionScheme)
+}
+
+func (c *pythonModuleVersion) constraint() versionConstraint {
+	return versionConstraint{
+		Scheme:          c.VersionScheme,
+		Range:           c.Range,
+		Version:         c.Version,
+		Relationship:    c.Relationship,
+		MinVersion:      c.MinVersion,
+		MinRelationship: c.MinRelationship,
+	}
+}
+
+// parseModuleVersion parses the version reported by the module. For
+// the default semver scheme, the reported version must be a complete
+// semantic version.
+func (c *pythonModuleVersion) parseModuleVersion(version string) (semver.Version, string, error) {
+	if c.VersionScheme == "" || c.VersionScheme == "semver" {
+		parsed, err := semver.Parse(version)
+		return parsed, version, err
+	}
+
+	return parseSchemeVersion(c.VersionScheme, version)
 }
 
 func (c *pythonModuleVersion) Run() {
@@ -77,32 +106,20 @@ func (c *pythonModuleVersion) Run() {
 		return
 	}
 
-	expected, err := semver.Parse(c.Version)
-	if err != nil {
+	if err := c.constraint().validate(); err != nil {
 		c.setState(false)
 		c.AddError(err)
-		c.setMessage(fmt.Sprintf("could not parse expected version '%s'", c.Version))
+		c.setMessage(fmt.Sprintf("could not parse expected version for module '%s'", c.Module))
 		return
 	}
 
-	var minExpected semver.Version
-	if c.MinVersion != "" {
-		minExpected, err = semver.Parse(c.MinVersion)
-		if err != nil {
-			c.setState(false)
-			c.AddError(err)
-			c.setMessage(fmt.Sprintf("could not parse expected version '%s'", c.Version))
-			return
-		}
-	}
-
 	cmdArgs := []string{
 		c.PythonInterpreter, "-c",
 		fmt.Sprintf("import %s; print(%s)", c.Module, c.Statement),
 	}
 
 	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	versionOut, err := cmd.Output()
+	versionOut, err := commandOptions{}.withDefaultLimits().output(cmd)
 	version := strings.Trim(string(versionOut), "\r\t\n ")
 	if err != nil {
 		c.setState(false)
@@ -111,7 +128,7 @@ func (c *pythonModuleVersion) Run() {
 		return
 	}
 
-	parsed, err := semver.Parse(version)
+	parsed, normalized, err := c.parseModuleVersion(version)
 	if err != nil {
 		c.setState(false)
 		c.AddError(err)
@@ -120,8 +137,7 @@ func (c *pythonModuleVersion) Run() {
 		return
 	}
 
-	var result bool
-	result, err = compareVersions(c.Relationship, parsed, expected)
+	result, err := c.constraint().satisfiedBy(parsed)
 	if err != nil {
 		// this should be unreachable, because the earlier
 		// validate will have caught it.
@@ -130,20 +146,10 @@ func (c *pythonModuleVersion) Run() {
 		return
 	}
 
-	if c.MinVersion != "" {
-		gteMin, err := compareVersions(c.MinRelationship, parsed, minExpected)
-		if err != nil {
-			c.setState(false)
-			c.AddError(err)
-			return
-		}
-
-		result = result && gteMin
-	}
-
 	if !result {
 		c.setState(false)
-		msg := fmt.Sprintln(parsed, c.Relationship, expected)
+		msg := fmt.Sprintf("module '%s' version is '%s' (normalized: %s), expected %s",
+			c.Module, version, normalized, c.constraint())
 		c.AddError(errors.Errorf("check failed: %s", msg))
 		c.setMessage(msg)
 		return
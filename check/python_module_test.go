@@ -152,3 +152,65 @@ func (s *PythonModuleSuite) TestMinMaxVersionReturnsErrorWhenExpectedValueDoesNo
 	s.Error(s.check.Error())
 	s.False(s.check.Output().Passed)
 }
+
+func (s *PythonModuleSuite) TestVersionRangeExpressions() {
+	s.check.Relationship = ""
+	s.check.Version = ""
+	s.check.Statement = "'2.7.13'"
+
+	s.check.Range = ">=2.7.0 <3.0.0 || >=3.6.0"
+	s.check.Run()
+	s.NoError(s.check.Error())
+	s.True(s.check.Output().Passed)
+
+	s.SetupTest()
+	s.check.Statement = "'3.5.2'"
+	s.check.Range = ">=2.7.0 <3.0.0 || >=3.6.0"
+	s.check.Run()
+	s.Error(s.check.Error())
+	s.False(s.check.Output().Passed)
+}
+
+func (s *PythonModuleSuite) TestInvalidVersionRangeTriggersError() {
+	s.check.Range = ">=foo"
+
+	s.check.Run()
+	s.Error(s.check.Error())
+	s.False(s.check.Output().Passed)
+}
+
+func (s *PythonModuleSuite) TestVersionSchemes() {
+	s.check.Statement = "'2.0.0rc1'"
+	s.check.Version = "2.0"
+	s.check.Relationship = "lt"
+
+	s.check.Run()
+	s.Error(s.check.Error(), "semver is the default scheme")
+	s.False(s.check.Output().Passed)
+
+	for _, scheme := range []string{"pep440", "loose"} {
+		s.SetupTest()
+		s.check.Statement = "'2.0.0rc1'"
+		s.check.Version = "2.0"
+		s.check.Relationship = "lt"
+		s.check.VersionScheme = scheme
+
+		s.check.Run()
+		s.NoError(s.check.Error(), scheme)
+		s.True(s.check.Output().Passed, scheme)
+	}
+
+	s.SetupTest()
+	s.check.Statement = "'0.9.post3'"
+	s.check.Version = "0.9.1"
+	s.check.VersionScheme = "pep440"
+	s.check.Relationship = "gte"
+	s.check.Run()
+	output := s.check.Output()
+	s.False(output.Passed)
+	s.Contains(output.Message, "'0.9.post3' (normalized: 0.9.post3)")
+
+	s.SetupTest()
+	s.check.VersionScheme = "debian"
+	s.Error(s.check.validate())
+}
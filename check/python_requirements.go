@@ -0,0 +1,395 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "python-requirements-satisfied"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &pythonRequirementsSatisfied{
+			Base: NewBase(name, 0),
+		}
+	})
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Requirements File Parsing and Version Specifiers
+//
+////////////////////////////////////////////////////////////////////////
+
+// pep440Specifier is a single clause of a PEP 440 version specifier,
+// e.g. ">=1.11" or "==2.0.*".
+type pep440Specifier struct {
+	operator string
+	version  string
+}
+
+var pep440SpecifierPattern = regexp.MustCompile(`^(~=|===|==|!=|<=|>=|<|>)\s*(\S+)$`)
+
+func parsePEP440Specifiers(spec string) ([]pep440Specifier, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "(") && strings.HasSuffix(spec, ")") {
+		spec = spec[1 : len(spec)-1]
+	}
+
+	var out []pep440Specifier
+	for _, clause := range strings.Split(spec, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+
+		match := pep440SpecifierPattern.FindStringSubmatch(clause)
+		if match == nil {
+			return nil, errors.Errorf("'%s' is not a valid version specifier", clause)
+		}
+
+		s := pep440Specifier{operator: match[1], version: match[2]}
+
+		switch {
+		case s.operator == "===":
+		case strings.HasSuffix(s.version, ".*"):
+			if s.operator != "==" && s.operator != "!=" {
+				return nil, errors.Errorf("wildcards are not valid with '%s' in '%s'", s.operator, clause)
+			}
+			if _, _, err := parsePEP440Version(strings.TrimSuffix(s.version, ".*")); err != nil {
+				return nil, err
+			}
+		default:
+			if _, _, err := parsePEP440Version(s.version); err != nil {
+				return nil, err
+			}
+			if s.operator == "~=" && len(pep440Release(s.version)) < 2 {
+				return nil, errors.Errorf("'%s' requires at least two release segments", clause)
+			}
+		}
+
+		out = append(out, s)
+	}
+
+	return out, nil
+}
+
+// pep440Release returns the release segments of a PEP 440 version.
+func pep440Release(version string) []string {
+	match := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if match == nil {
+		return nil
+	}
+
+	return strings.Split(match[2], ".")
+}
+
+// pep440PrefixMatch reports whether the release segments of version
+// begin with prefix, padding the release with zeros as needed.
+func pep440PrefixMatch(version string, prefix []string) bool {
+	release := pep440Release(version)
+
+	for idx, segment := range prefix {
+		expected, _ := strconv.ParseUint(segment, 10, 64)
+
+		var actual uint64
+		if idx < len(release) {
+			actual, _ = strconv.ParseUint(release[idx], 10, 64)
+		}
+
+		if actual != expected {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s pep440Specifier) String() string {
+	return s.operator + s.version
+}
+
+func (s pep440Specifier) satisfiedBy(version string) (bool, error) {
+	if s.operator == "===" {
+		return version == s.version, nil
+	}
+
+	if strings.HasSuffix(s.version, ".*") {
+		match := pep440PrefixMatch(version, pep440Release(strings.TrimSuffix(s.version, ".*")))
+		return match == (s.operator == "=="), nil
+	}
+
+	actual, _, err := parsePEP440Version(version)
+	if err != nil {
+		return false, err
+	}
+
+	expected, _, err := parsePEP440Version(s.version)
+	if err != nil {
+		return false, err
+	}
+
+	switch s.operator {
+	case "~=":
+		release := pep440Release(s.version)
+		return actual.GTE(expected) && pep440PrefixMatch(version, release[:len(release)-1]), nil
+	case "==":
+		return actual.EQ(expected), nil
+	case "!=":
+		return actual.NE(expected), nil
+	case "<=":
+		return actual.LTE(expected), nil
+	case ">=":
+		return actual.GTE(expected), nil
+	case "<":
+		return actual.LT(expected), nil
+	default:
+		return actual.GT(expected), nil
+	}
+}
+
+// pythonRequirement is a single requirement from a requirements
+// file. Extras are recorded, but the requirements of extras are not
+// checked.
+type pythonRequirement struct {
+	name       string
+	extras     string
+	specifiers []pep440Specifier
+	line       string
+}
+
+var pythonRequirementPattern = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)\s*(\[[^\]]*\])?\s*(.*)$`)
+
+func parsePythonRequirement(line string) (*pythonRequirement, error) {
+	// environment markers are not evaluated: requirements always apply.
+	spec := line
+	if idx := strings.Index(spec, ";"); idx >= 0 {
+		grip.Debugf("ignoring environment marker in requirement '%s'", line)
+		spec = spec[:idx]
+	}
+
+	match := pythonRequirementPattern.FindStringSubmatch(strings.TrimSpace(spec))
+	if match == nil {
+		return nil, errors.Errorf("'%s' is not a valid requirement", line)
+	}
+
+	req := &pythonRequirement{
+		name:   match[1],
+		extras: match[2],
+		line:   line,
+	}
+
+	if strings.HasPrefix(match[3], "@") {
+		// direct references only require that the
+		// distribution is installed.
+		return req, nil
+	}
+
+	specifiers, err := parsePEP440Specifiers(match[3])
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem parsing requirement '%s'", line)
+	}
+	req.specifiers = specifiers
+
+	return req, nil
+}
+
+func (r *pythonRequirement) String() string {
+	out := make([]string, 0, len(r.specifiers))
+	for _, s := range r.specifiers {
+		out = append(out, s.String())
+	}
+
+	return r.name + r.extras + strings.Join(out, ",")
+}
+
+// satisfiedBy reports whether an installed version satisfies every
+// specifier of the requirement.
+func (r *pythonRequirement) satisfiedBy(version string) (bool, error) {
+	for _, s := range r.specifiers {
+		ok, err := s.satisfiedBy(version)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// parseRequirementsFile reads a pip requirements file, including
+// files referenced with "-r" (relative to the including file).
+// Editable requirements and other pip options are skipped.
+func parseRequirementsFile(fn string, seen map[string]bool) ([]*pythonRequirement, error) {
+	abs, err := filepath.Abs(fn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem resolving path '%s'", fn)
+	}
+	if seen[abs] {
+		return nil, errors.Errorf("requirements file '%s' includes itself", fn)
+	}
+	seen[abs] = true
+	defer delete(seen, abs)
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem opening requirements file '%s'", fn)
+	}
+	defer f.Close()
+
+	var out []*pythonRequirement
+	var line string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line += scanner.Text()
+		if strings.HasSuffix(line, `\`) {
+			line = strings.TrimSuffix(line, `\`)
+			continue
+		}
+
+		current := line
+		line = ""
+
+		if idx := strings.Index(current, "#"); idx == 0 || (idx > 0 && strings.ContainsAny(current[idx-1:idx], " \t")) {
+			current = current[:idx]
+		}
+		current = strings.TrimSpace(current)
+
+		switch {
+		case current == "":
+			continue
+		case strings.HasPrefix(current, "-r ") || strings.HasPrefix(current, "--requirement"):
+			included := strings.TrimSpace(strings.TrimLeft(strings.TrimPrefix(strings.TrimPrefix(current, "--requirement"), "-r "), "= "))
+			if !filepath.IsAbs(included) {
+				included = filepath.Join(filepath.Dir(fn), included)
+			}
+
+			reqs, err := parseRequirementsFile(included, seen)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, reqs...)
+		case strings.HasPrefix(current, "-"):
+			grip.Debugf("skipping option '%s' in requirements file '%s'", current, fn)
+		default:
+			req, err := parsePythonRequirement(current)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing requirements file '%s'", fn)
+			}
+			out = append(out, req)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "problem reading requirements file '%s'", fn)
+	}
+
+	return out, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Implementation of the Python Requirements Check
+//
+////////////////////////////////////////////////////////////////////////
+
+// pythonRequirementsSatisfied checks that the distributions installed
+// for a python interpreter satisfy a requirements file, reporting all
+// missing and mismatched requirements.
+type pythonRequirementsSatisfied struct {
+	FileName          string     `bson:"name" json:"name" yaml:"name"`
+	PythonInterpreter string     `bson:"python" json:"python" yaml:"python"`
+	RunAs             *runAsSpec `bson:"run_as" json:"run_as" yaml:"run_as"`
+	*Base             `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	inventory *packageInventory
+}
+
+func (c *pythonRequirementsSatisfied) validate() error {
+	if c.FileName == "" {
+		return errors.Errorf("no requirements file specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.PythonInterpreter == "" {
+		c.PythonInterpreter = "python"
+		grip.Debug("no python interpreter specified, using default python from PATH")
+	}
+
+	if c.inventory == nil {
+		inventory, err := packageInventoryRegistry["pip"].forTool(packageTool{Interpreter: c.PythonInterpreter, RunAs: c.RunAs})
+		if err != nil {
+			return errors.Wrapf(err, "problem finding packages for '%s'", c.PythonInterpreter)
+		}
+		c.inventory = inventory
+	}
+
+	return nil
+}
+
+func (c *pythonRequirementsSatisfied) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	reqs, err := parseRequirementsFile(c.FileName, map[string]bool{})
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var problems []string
+	for _, req := range reqs {
+		version, ok, err := c.inventory.lookup(req.name)
+		if err != nil {
+			c.setState(false)
+			c.AddError(err)
+			return
+		}
+
+		if !ok {
+			problems = append(problems, fmt.Sprintf("requirement '%s' is not installed", req))
+			continue
+		}
+
+		result, err := req.satisfiedBy(version)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("requirement '%s' could not be checked against version '%s': %s",
+				req, version, err))
+			continue
+		}
+
+		if !result {
+			problems = append(problems, fmt.Sprintf("requirement '%s' is not satisfied by installed version '%s'",
+				req, version))
+		}
+	}
+
+	grip.Debugf("python requirements check '%s' found %d of %d requirements not satisfied (checked with %s)",
+		c.ID(), len(problems), len(reqs), c.PythonInterpreter)
+
+	if len(problems) > 0 {
+		c.setState(false)
+		c.setMessage(problems)
+		c.AddError(errors.Errorf("%d of %d requirements in '%s' are not satisfied (checked with %s)",
+			len(problems), len(reqs), c.FileName, c.PythonInterpreter))
+		return
+	}
+
+	c.setState(true)
+}
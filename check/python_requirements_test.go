@@ -0,0 +1,161 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPEP440Specifiers(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]map[string]bool{
+		">=1.11,<2.0": {"1.11": true, "1.11.29": true, "2.0": false, "1.10.8": false},
+		"(>=1.0)":     {"1.0": true, "0.9": false},
+		"==2.18.4":    {"2.18.4": true, "2.18.4.0": true, "2.18.5": false},
+		"==1.4.*":     {"1.4": true, "1.4.2": true, "1.5": false, "1.40": false},
+		"!=1.4.*":     {"1.4.2": false, "1.5.0": true},
+		"!=2.0":       {"2.0": false, "2.0.1": true},
+		"~=2.2":       {"2.2": true, "2.9.1": true, "3.0": false, "2.1": false},
+		"~=1.4.5":     {"1.4.5": true, "1.4.9": true, "1.5.0": false},
+		">1.0,<=1.2":  {"1.0": false, "1.1": true, "1.2": true, "1.2.1": false},
+		">=2.0rc1":    {"2.0b1": false, "2.0rc1": true, "2.0": true},
+		"===1.0-foo":  {"1.0-foo": true, "1.0": false},
+		"==1.2.3.4":   {"1.2.3.4": true, "1.2.3.4.0": true, "1.2.3.5": false, "1.2.3": false},
+		">2.0.0":      {"2.0.0.1": true, "2.0.0.0": false, "2.0.0rc1": false},
+		"<1.2.3.5":    {"1.2.3.4": true, "1.2.3.5": false, "1.2.3.10": false},
+	}
+
+	for spec, versions := range cases {
+		specifiers, err := parsePEP440Specifiers(spec)
+		assert.NoError(err, spec)
+
+		req := &pythonRequirement{name: "pkg", specifiers: specifiers}
+		for version, expected := range versions {
+			result, err := req.satisfiedBy(version)
+			assert.NoError(err, "%s %s", spec, version)
+			assert.Equal(expected, result, "%s %s", spec, version)
+		}
+	}
+
+	for _, spec := range []string{"=>1.0", ">=1.*", "~=1", "==foo", ">= 1.0 2.0"} {
+		_, err := parsePEP440Specifiers(spec)
+		assert.Error(err, spec)
+	}
+}
+
+func TestPythonRequirementParser(t *testing.T) {
+	assert := assert.New(t)
+
+	req, err := parsePythonRequirement(`requests[security,socks] >= 2.18 ; python_version < "3"`)
+	assert.NoError(err)
+	assert.Equal("requests", req.name)
+	assert.Equal("[security,socks]", req.extras)
+	assert.Equal("requests[security,socks]>=2.18", req.String())
+
+	req, err = parsePythonRequirement("zope.interface")
+	assert.NoError(err)
+	assert.Equal("zope.interface", req.name)
+	assert.Len(req.specifiers, 0)
+
+	req, err = parsePythonRequirement("pip @ https://example.com/pip.zip")
+	assert.NoError(err)
+	assert.Equal("pip", req.name)
+	assert.Len(req.specifiers, 0)
+
+	for _, line := range []string{"-foo", "requests >== 2.0", "[extras]"} {
+		_, err = parsePythonRequirement(line)
+		assert.Error(err, line)
+	}
+}
+
+func TestPythonRequirementsFileParser(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-requirements-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.txt")
+	require.NoError(ioutil.WriteFile(base, []byte("six==1.11.0 # pinned\n"), 0644))
+	main := filepath.Join(dir, "requirements.txt")
+	require.NoError(ioutil.WriteFile(main, []byte(
+		"# comment\n"+
+			"--index-url https://pypi.org/simple\n"+
+			"-r base.txt\n"+
+			"-e git+https://example.com/repo.git#egg=foo\n"+
+			"Django>=1.11, \\\n    <2.0\n"+
+			"\n"+
+			"requests[security]==2.18.4\n"), 0644))
+
+	reqs, err := parseRequirementsFile(main, map[string]bool{})
+	require.NoError(err)
+	require.Len(reqs, 3)
+	assert.Equal("six==1.11.0", reqs[0].String())
+	assert.Equal("Django>=1.11,<2.0", reqs[1].String())
+	assert.Equal("requests[security]==2.18.4", reqs[2].String())
+
+	loop := filepath.Join(dir, "loop.txt")
+	require.NoError(ioutil.WriteFile(loop, []byte("-r loop.txt\n"), 0644))
+	_, err = parseRequirementsFile(loop, map[string]bool{})
+	assert.Error(err)
+
+	_, err = parseRequirementsFile(filepath.Join(dir, "missing.txt"), map[string]bool{})
+	assert.Error(err)
+}
+
+func TestPythonRequirementsSatisfiedCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-requirements-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	inventory := &packageInventory{
+		args:      []string{"echo", "Django==1.11.29\nsix==1.10.0\nrequests==2.18.4"},
+		parse:     parsePipInventory,
+		normalize: normalizePythonPackageName,
+	}
+
+	satisfied := filepath.Join(dir, "satisfied.txt")
+	require.NoError(ioutil.WriteFile(satisfied, []byte("django>=1.11,<2.0\nRequests[security]\n"), 0644))
+	unsatisfied := filepath.Join(dir, "unsatisfied.txt")
+	require.NoError(ioutil.WriteFile(unsatisfied, []byte("django>=1.11,<2.0\nsix>=1.11\npyyaml\n"), 0644))
+
+	check := &pythonRequirementsSatisfied{
+		FileName:  satisfied,
+		Base:      NewBase("python-requirements-satisfied", 0),
+		inventory: inventory,
+	}
+	check.Run()
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	check = &pythonRequirementsSatisfied{
+		FileName:  unsatisfied,
+		Base:      NewBase("python-requirements-satisfied", 0),
+		inventory: inventory,
+	}
+	check.Run()
+	assert.Error(check.Error())
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "requirement 'six>=1.11' is not satisfied by installed version '1.10.0'")
+	assert.Contains(output.Message, "requirement 'pyyaml' is not installed")
+	assert.NotContains(output.Message, "django")
+
+	for _, check := range []*pythonRequirementsSatisfied{
+		{Base: NewBase("python-requirements-satisfied", 0), inventory: inventory},
+		{FileName: filepath.Join(dir, "missing.txt"), Base: NewBase("python-requirements-satisfied", 0), inventory: inventory},
+	} {
+		check.Run()
+		assert.Error(check.Error())
+		assert.False(check.Output().Passed)
+	}
+}
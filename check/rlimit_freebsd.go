@@ -0,0 +1,10 @@
+// +build freebsd
+
+package check
+
+import "syscall"
+
+// freebsd uses signed values for resource limits.
+func newRlimit(soft, hard uint64) *syscall.Rlimit {
+	return &syscall.Rlimit{Cur: int64(soft), Max: int64(hard)}
+}
@@ -0,0 +1,9 @@
+// +build linux solaris darwin
+
+package check
+
+import "syscall"
+
+func newRlimit(soft, hard uint64) *syscall.Rlimit {
+	return &syscall.Rlimit{Cur: soft, Max: hard}
+}
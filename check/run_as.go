@@ -0,0 +1,244 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// runAsSpec describes the identity that subprocess-based checks run
+// commands as. User is a user name or uid. Group, a group name or gid,
+// defaults to the user's primary group, and Groups, the supplementary
+// groups, defaults to the groups that the user belongs to.
+type runAsSpec struct {
+	User   string   `bson:"user" json:"user" yaml:"user"`
+	Group  string   `bson:"group" json:"group" yaml:"group"`
+	Groups []string `bson:"groups" json:"groups" yaml:"groups"`
+}
+
+// processIdentity is a resolved runAsSpec. When greenbay already runs
+// as the requested user and group, unchanged is true, and commands run
+// without switching credentials.
+type processIdentity struct {
+	uid         uint32
+	gid         uint32
+	groups      []uint32
+	username    string
+	home        string
+	description string
+	unchanged   bool
+}
+
+func parseIDs(ids []string) ([]uint32, error) {
+	out := make([]uint32, 0, len(ids))
+	for _, id := range ids {
+		num, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "'%s' is not a numeric id", id)
+		}
+		out = append(out, uint32(num))
+	}
+
+	return out, nil
+}
+
+func describeGroup(gid uint32) string {
+	if g, err := user.LookupGroupId(fmt.Sprint(gid)); err == nil {
+		return fmt.Sprintf("%d(%s)", gid, g.Name)
+	}
+
+	return fmt.Sprint(gid)
+}
+
+func describeUser(uid uint32) string {
+	if u, err := user.LookupId(fmt.Sprint(uid)); err == nil {
+		return fmt.Sprintf("%d(%s)", uid, u.Username)
+	}
+
+	return fmt.Sprint(uid)
+}
+
+// resolve looks up the user and groups described by the spec, and
+// returns an error if greenbay does not have the privilege to run
+// commands as that user. Nil specs, and specs without a user, resolve
+// to a nil identity, which runs commands as greenbay's own user.
+func (r *runAsSpec) resolve() (*processIdentity, error) {
+	if r == nil || r.User == "" {
+		if r != nil && (r.Group != "" || len(r.Groups) > 0) {
+			return nil, errors.New("run_as must specify a user")
+		}
+		return nil, nil
+	}
+
+	p := &processIdentity{}
+
+	u, err := user.Lookup(r.User)
+	if err != nil {
+		u, err = user.LookupId(r.User)
+	}
+
+	if err == nil {
+		p.username = u.Username
+		p.home = u.HomeDir
+		if p.uid, err = resolveUserID(u.Uid); err != nil {
+			return nil, err
+		}
+	} else {
+		// allow numeric ids without an entry in the user
+		// database, as long as the group is specified.
+		if p.uid, err = resolveUserID(r.User); err != nil {
+			return nil, errors.Wrapf(err, "problem resolving run_as user '%s'", r.User)
+		}
+	}
+
+	switch {
+	case r.Group != "":
+		if p.gid, err = resolveGroupID(r.Group); err != nil {
+			return nil, err
+		}
+	case u != nil:
+		if p.gid, err = resolveGroupID(u.Gid); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("run_as user '%s' has no user database entry, specify a group", r.User)
+	}
+
+	if len(r.Groups) > 0 {
+		for _, g := range r.Groups {
+			gid, err := resolveGroupID(g)
+			if err != nil {
+				return nil, err
+			}
+			p.groups = append(p.groups, gid)
+		}
+	} else if u != nil {
+		ids, err := u.GroupIds()
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem finding groups for user '%s'", u.Username)
+		}
+
+		if p.groups, err = parseIDs(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	groups := make([]string, 0, len(p.groups))
+	for _, gid := range p.groups {
+		groups = append(groups, describeGroup(gid))
+	}
+
+	p.description = fmt.Sprintf("uid=%d", p.uid)
+	if p.username != "" {
+		p.description += fmt.Sprintf("(%s)", p.username)
+	}
+	p.description += fmt.Sprintf(" gid=%s", describeGroup(p.gid))
+	if len(groups) > 0 {
+		p.description += fmt.Sprintf(" groups=%s", strings.Join(groups, ","))
+	}
+
+	if err = checkRunAsPrivilege(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *processIdentity) String() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.description
+}
+
+// environment sets the variables that describe the user (HOME, USER,
+// and LOGNAME) in env, which defaults to the process environment.
+func (p *processIdentity) environment(env []string) []string {
+	if p == nil {
+		return env
+	}
+
+	if env == nil {
+		env = os.Environ()
+	}
+
+	if p.username == "" {
+		return env
+	}
+
+	identity := map[string]string{
+		"HOME":    p.home,
+		"USER":    p.username,
+		"LOGNAME": p.username,
+	}
+
+	out := make([]string, 0, len(env)+len(identity))
+	for _, pair := range env {
+		if _, ok := identity[strings.SplitN(pair, "=", 2)[0]]; !ok {
+			out = append(out, pair)
+		}
+	}
+
+	for _, key := range []string{"HOME", "USER", "LOGNAME"} {
+		out = append(out, fmt.Sprintf("%s=%s", key, identity[key]))
+	}
+
+	return out
+}
+
+// chown gives the user ownership of files that greenbay creates for
+// commands to use, like the source files of compile checks.
+func (p *processIdentity) chown(paths ...string) error {
+	if p == nil || p.unchanged {
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := os.Chown(path, int(p.uid), int(p.gid)); err != nil {
+			return errors.Wrapf(err, "problem giving %s ownership of '%s'", p, path)
+		}
+	}
+
+	return nil
+}
+
+// configure sets the credentials of the command and the variables
+// that describe the user in its environment.
+func (p *processIdentity) configure(cmd *exec.Cmd) {
+	if p == nil {
+		return
+	}
+
+	p.apply(cmd)
+	cmd.Env = p.environment(cmd.Env)
+}
+
+// describeOutput prefixes the output of a command that ran as the
+// identity with a description of the identity.
+func (p *processIdentity) describeOutput(output string) string {
+	switch {
+	case p == nil:
+		return output
+	case output == "":
+		return fmt.Sprintf("ran as %s", p)
+	default:
+		return fmt.Sprintf("ran as %s\n%s", p, output)
+	}
+}
+
+// isCredentialError reports whether err is the error that starting a
+// command returns when greenbay cannot switch to its credentials.
+func isCredentialError(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+
+	return err == syscall.EPERM
+}
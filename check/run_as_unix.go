@@ -0,0 +1,47 @@
+// +build linux freebsd solaris darwin
+
+package check
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// checkRunAsPrivilege returns an error if greenbay cannot switch to
+// the identity, which requires running as root, unless greenbay
+// already runs as the requested user and group.
+func checkRunAsPrivilege(p *processIdentity) error {
+	euid := os.Geteuid()
+	if euid == 0 {
+		return nil
+	}
+
+	if uint32(euid) == p.uid && uint32(os.Getegid()) == p.gid {
+		p.unchanged = true
+		return nil
+	}
+
+	return errors.Errorf("greenbay is running as uid=%d and lacks the privilege to run commands as %s "+
+		"(run_as requires running greenbay as root)", euid, p)
+}
+
+// apply configures the command to run with the identity's
+// credentials.
+func (p *processIdentity) apply(cmd *exec.Cmd) {
+	if p == nil || p.unchanged {
+		return
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    p.uid,
+		Gid:    p.gid,
+		Groups: p.groups,
+	}
+}
@@ -0,0 +1,153 @@
+// +build linux freebsd solaris darwin
+
+package check
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAsResolution(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var spec *runAsSpec
+	identity, err := spec.resolve()
+	assert.NoError(err)
+	assert.Nil(identity)
+
+	_, err = (&runAsSpec{Group: "0"}).resolve()
+	assert.Error(err)
+
+	_, err = (&runAsSpec{User: "greenbay-user-does-not-exist"}).resolve()
+	assert.Error(err)
+
+	current, err := user.Current()
+	require.NoError(err)
+
+	for _, name := range []string{current.Username, current.Uid} {
+		identity, err = (&runAsSpec{User: name}).resolve()
+		require.NoError(err, name)
+		assert.Equal(fmt.Sprintf("%d", identity.uid), current.Uid)
+		assert.Contains(identity.String(), fmt.Sprintf("uid=%s(%s)", current.Uid, current.Username))
+		assert.Equal(os.Geteuid() != 0, identity.unchanged)
+	}
+
+	env := identity.environment([]string{"HOME=/elsewhere", "FOO=bar"})
+	assert.Contains(env, "FOO=bar")
+	assert.Contains(env, "HOME="+current.HomeDir)
+	assert.NotContains(env, "HOME=/elsewhere")
+}
+
+func TestRunAsWithoutPrivilege(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("test requires running as a user other than root")
+	}
+
+	assert := assert.New(t)
+
+	_, err := (&runAsSpec{User: "root"}).resolve()
+	assert.Error(err)
+	assert.Contains(err.Error(), "lacks the privilege")
+
+	check := &shellOperation{
+		Command: "true",
+		RunAs:   &runAsSpec{User: "root"},
+		Base:    NewBase("shell-operation", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "lacks the privilege")
+}
+
+func TestRunAsOtherUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("test requires running as root")
+	}
+
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip("test requires a 'nobody' user")
+	}
+
+	assert := assert.New(t)
+
+	check := &shellOperation{
+		Command: "id -u",
+		RunAs:   &runAsSpec{User: "nobody"},
+		Stdout:  outputAssertions{Matches: []string{"^" + nobody.Uid + "$"}},
+		Base:    NewBase("shell-operation", 0),
+	}
+	check.Run()
+	output := check.Output()
+	assert.True(output.Passed, output.Message)
+	assert.Contains(output.Message, fmt.Sprintf("ran as uid=%s(nobody)", nobody.Uid))
+
+	group := &shellGroup{
+		Commands:     []*shellOperation{{Command: "test \"$(id -u)\" = " + nobody.Uid}},
+		RunAs:        &runAsSpec{User: "nobody"},
+		Requirements: GroupRequirements{All: true, Name: "all"},
+		Base:         NewBase("command-group-all", 0),
+	}
+	group.Run()
+	assert.True(group.Output().Passed, group.Output().Message)
+
+	script := &compileCheck{
+		Source:        "test \"$(id -u)\" = " + nobody.Uid,
+		RunAs:         &runAsSpec{User: "nobody"},
+		Base:          NewBase("compile-and-run-sh-script", 0),
+		shouldRunCode: true,
+		compiler:      compileScript{bin: "/bin/sh"},
+	}
+	script.Run()
+	output = script.Output()
+	assert.True(output.Passed, output.Message)
+	assert.Contains(output.Message, "ran as uid="+nobody.Uid)
+
+	version := &programVersion{
+		Command:      "id",
+		Args:         []string{"-u"},
+		Pattern:      `^(\d+)$`,
+		RunAs:        &runAsSpec{User: "nobody"},
+		Version:      nobody.Uid,
+		Relationship: "eq",
+		Base:         NewBase("program-version", 0),
+	}
+	version.Run()
+	output = version.Output()
+	assert.True(output.Passed, output.Message)
+	assert.Contains(output.Message, "ran as uid="+nobody.Uid)
+}
+
+func TestPackageInventoryRunAs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	current, err := user.Current()
+	require.NoError(err)
+
+	inventory := &packageInventory{
+		args:  []string{"echo", "foo 1.0"},
+		parse: parseFieldsInventory,
+	}
+
+	derived, err := inventory.forTool(packageTool{RunAs: &runAsSpec{User: current.Username}})
+	require.NoError(err)
+	assert.NotEqual(inventory, derived)
+	assert.Equal(inventory.args, derived.args)
+
+	ok, msg, err := derived.installed("foo")
+	assert.NoError(err)
+	assert.True(ok, msg)
+	assert.Contains(msg, "checked with echo as uid="+current.Uid)
+
+	again, err := inventory.forTool(packageTool{RunAs: &runAsSpec{User: current.Uid}})
+	require.NoError(err)
+	assert.Equal(derived, again)
+}
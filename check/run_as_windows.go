@@ -0,0 +1,17 @@
+// +build windows
+
+package check
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func checkRunAsPrivilege(p *processIdentity) error {
+	return errors.Errorf("running commands as %s is not supported on this platform (%s)",
+		p, runtime.GOOS)
+}
+
+func (p *processIdentity) apply(cmd *exec.Cmd) {}
@@ -0,0 +1,91 @@
+package check
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// splitCommandLine splits a command line into arguments following
+// POSIX shell quoting rules: arguments are separated by whitespace,
+// single quotes preserve their contents literally, double quotes
+// preserve their contents except for backslash escapes of '"', '\',
+// '$', and '`', and a backslash outside of quotes escapes the next
+// character. Unlike a shell, this does not expand variables, globs,
+// or other special characters.
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+
+	runes := []rune(line)
+	for idx := 0; idx < len(runes); idx++ {
+		r := runes[idx]
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case r == '\\':
+			inArg = true
+			idx++
+			if idx >= len(runes) {
+				return nil, errors.Errorf("command '%s' ends with an escape character", line)
+			}
+			if runes[idx] != '\n' {
+				current.WriteRune(runes[idx])
+			}
+		case r == '\'':
+			inArg = true
+			closed := false
+			for idx++; idx < len(runes); idx++ {
+				if runes[idx] == '\'' {
+					closed = true
+					break
+				}
+
+				current.WriteRune(runes[idx])
+			}
+
+			if !closed {
+				return nil, errors.Errorf("command '%s' has an unterminated single quote", line)
+			}
+		case r == '"':
+			inArg = true
+			closed := false
+			for idx++; idx < len(runes); idx++ {
+				r = runes[idx]
+				if r == '"' {
+					closed = true
+					break
+				}
+
+				if r == '\\' && idx+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[idx+1]) {
+					idx++
+					if runes[idx] != '\n' {
+						current.WriteRune(runes[idx])
+					}
+					continue
+				}
+
+				current.WriteRune(r)
+			}
+
+			if !closed {
+				return nil, errors.Errorf("command '%s' has an unterminated double quote", line)
+			}
+		default:
+			inArg = true
+			current.WriteRune(r)
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,37 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string][]string{
+		"":                             nil,
+		"   ":                          nil,
+		"true":                         {"true"},
+		"  echo   foo\tbar  ":          {"echo", "foo", "bar"},
+		`python -c 'print("a b")'`:     {"python", "-c", `print("a b")`},
+		`echo "a \"quoted\" $HOME \x"`: {"echo", `a "quoted" $HOME \x`},
+		`echo a\ b c\\d`:               {"echo", "a b", `c\d`},
+		`echo '' ""`:                   {"echo", "", ""},
+		`echo foo'bar'"baz"`:           {"echo", "foobarbaz"},
+		"echo a \\\nb":                 {"echo", "a", "b"},
+		`echo 'it'\''s'`:               {"echo", "it's"},
+		`echo 'ünïcode' "ü"`:           {"echo", "ünïcode", "ü"},
+	}
+
+	for line, expected := range cases {
+		args, err := splitCommandLine(line)
+		assert.NoError(err, line)
+		assert.Equal(expected, args, line)
+	}
+
+	for _, line := range []string{`echo 'foo`, `echo "foo`, `echo foo\`, `echo "foo\"`} {
+		_, err := splitCommandLine(line)
+		assert.Error(err, line)
+	}
+}
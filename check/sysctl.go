@@ -0,0 +1,207 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "sysctl"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &sysctlCheck{
+			Base:    NewBase(name, 0),
+			sysRoot: "/proc/sys",
+		}
+	})
+}
+
+// sysctlCheck checks a kernel parameter, by reading its value from
+// /proc/sys. Key is the name of the parameter in the dotted form that
+// sysctl(8) uses (e.g. "vm.swappiness"), where slashes stand for dots
+// in the path (e.g. "net.ipv4.conf.eth0/100.forwarding"). Values with
+// several fields (e.g. "net.ipv4.ip_local_port_range") are normalized
+// to fields separated by a single space, and Field selects one of the
+// fields, counting from 1, for all of the assertions.
+//
+// Value requires the value to equal a string, and Matches requires it
+// to match a regular expression. Compare is one of "eq", "ne", "lt",
+// "lte", "gt", or "gte", and compares every field of the value (or the
+// selected field) to Number, as integers. This check is only available
+// on linux.
+type sysctlCheck struct {
+	Key     string `bson:"key" json:"key" yaml:"key"`
+	Value   string `bson:"value" json:"value" yaml:"value"`
+	Matches string `bson:"matches" json:"matches" yaml:"matches"`
+	Compare string `bson:"compare" json:"compare" yaml:"compare"`
+	Number  string `bson:"number" json:"number" yaml:"number"`
+	Field   int    `bson:"field" json:"field" yaml:"field"`
+	*Base   `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	sysRoot string
+}
+
+// sysctlComparisons maps the comparison operators of sysctl checks to
+// functions that report if the result of big.Int.Cmp satisfies them.
+var sysctlComparisons = map[string]func(int) bool{
+	"eq":  func(cmp int) bool { return cmp == 0 },
+	"ne":  func(cmp int) bool { return cmp != 0 },
+	"lt":  func(cmp int) bool { return cmp < 0 },
+	"lte": func(cmp int) bool { return cmp <= 0 },
+	"gt":  func(cmp int) bool { return cmp > 0 },
+	"gte": func(cmp int) bool { return cmp >= 0 },
+}
+
+// sysctlPath translates the dotted name of a kernel parameter into
+// its path under /proc/sys.
+func sysctlPath(sysRoot, key string) string {
+	path := strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		default:
+			return r
+		}
+	}, key)
+
+	return filepath.Join(sysRoot, filepath.FromSlash(path))
+}
+
+func parseSysctlNumber(value string) (*big.Int, error) {
+	out, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, errors.Errorf("'%s' is not an integer", value)
+	}
+
+	return out, nil
+}
+
+func (c *sysctlCheck) validate() error {
+	catcher := grip.NewCatcher()
+
+	if c.Key == "" {
+		catcher.Add(errors.New("no sysctl key specified"))
+	}
+
+	if c.Value == "" && c.Matches == "" && c.Compare == "" {
+		catcher.Add(errors.Errorf("no assertions specified for sysctl '%s'", c.Key))
+	}
+
+	if c.Matches != "" {
+		if _, err := regexp.Compile(c.Matches); err != nil {
+			catcher.Add(errors.Wrapf(err, "problem compiling pattern '%s'", c.Matches))
+		}
+	}
+
+	if c.Compare != "" || c.Number != "" {
+		if _, ok := sysctlComparisons[c.Compare]; !ok {
+			catcher.Add(errors.Errorf("'%s' is not a valid comparison for sysctl '%s'", c.Compare, c.Key))
+		}
+
+		if _, err := parseSysctlNumber(c.Number); err != nil {
+			catcher.Add(errors.Wrapf(err, "invalid number for sysctl '%s'", c.Key))
+		}
+	}
+
+	if c.Field < 0 {
+		catcher.Add(errors.Errorf("field %d of sysctl '%s' is not valid", c.Field, c.Key))
+	}
+
+	return errors.Wrapf(catcher.Resolve(), "invalid '%s' (%s) check", c.ID(), c.Name())
+}
+
+// read returns the fields of the value of the kernel parameter.
+func (c *sysctlCheck) read() ([]string, error) {
+	fn := sysctlPath(c.sysRoot, c.Key)
+
+	data, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading sysctl '%s'", c.Key)
+	}
+
+	return strings.Fields(string(data)), nil
+}
+
+// evaluate returns a description of every assertion that the fields
+// of the value do not satisfy.
+func (c *sysctlCheck) evaluate(fields []string) []string {
+	name := c.Key
+	value := strings.Join(fields, " ")
+	if c.Field > 0 {
+		if c.Field > len(fields) {
+			return []string{fmt.Sprintf("sysctl %s = '%s' does not have field %d", c.Key, value, c.Field)}
+		}
+
+		name = fmt.Sprintf("field %d of sysctl %s", c.Field, c.Key)
+		fields = fields[c.Field-1 : c.Field]
+		value = fields[0]
+	}
+
+	var problems []string
+
+	if c.Value != "" && value != strings.Join(strings.Fields(c.Value), " ") {
+		problems = append(problems, fmt.Sprintf("%s is '%s', expected '%s'", name, value, c.Value))
+	}
+
+	if c.Matches != "" && !regexp.MustCompile(c.Matches).MatchString(value) {
+		problems = append(problems, fmt.Sprintf("%s is '%s', which does not match '%s'", name, value, c.Matches))
+	}
+
+	if c.Compare != "" {
+		expected, _ := parseSysctlNumber(c.Number)
+		for _, field := range fields {
+			actual, err := parseSysctlNumber(field)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s is '%s', which is not an integer", name, value))
+				break
+			}
+
+			if !sysctlComparisons[c.Compare](actual.Cmp(expected)) {
+				problems = append(problems, fmt.Sprintf("%s is '%s', expected %s %s", name, value, c.Compare, c.Number))
+				break
+			}
+		}
+	}
+
+	return problems
+}
+
+func (c *sysctlCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	fields, err := c.read()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	problems := c.evaluate(fields)
+	if len(problems) > 0 {
+		c.setState(false)
+		c.setMessage(problems)
+		c.AddError(errors.Errorf("sysctl '%s' does not satisfy check requirements", c.Key))
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("%s = %s", c.Key, strings.Join(fields, " ")))
+	c.setState(true)
+}
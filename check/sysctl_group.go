@@ -0,0 +1,70 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/greenbay"
+	"github.com/pkg/errors"
+)
+
+func registerSysctlGroupChecks() {
+	sysctlGroupFactoryFactory := func(name string, gr GroupRequirements) func() amboy.Job {
+		gr.Name = name
+		return func() amboy.Job {
+			return &sysctlGroup{
+				Base:         NewBase(name, 0),
+				Requirements: gr,
+				sysRoot:      "/proc/sys",
+			}
+		}
+	}
+
+	for group, requirements := range groupRequirementRegistry {
+		name := fmt.Sprintf("sysctl-group-%s", group)
+		registry.AddJobType(name, sysctlGroupFactoryFactory(name, requirements))
+	}
+}
+
+// sysctlGroup checks a set of kernel parameters, such as a tuning
+// profile, with the semantics of sysctl checks.
+type sysctlGroup struct {
+	Checks       []*sysctlCheck    `bson:"checks" json:"checks" yaml:"checks"`
+	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	sysRoot string
+}
+
+func (c *sysctlGroup) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.Requirements.Validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if len(c.Checks) == 0 {
+		c.setState(false)
+		c.AddError(errors.Errorf("no sysctl checks specified for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
+	checks := make([]greenbay.Checker, 0, len(c.Checks))
+	for idx, check := range c.Checks {
+		if check.Base == nil {
+			check.Base = NewBase(fmt.Sprintf("%s-%d", c.ID(), idx), c.Type().Version)
+		}
+		if check.sysRoot == "" {
+			check.sysRoot = c.sysRoot
+		}
+
+		checks = append(checks, check)
+	}
+
+	c.runGroupChecks(c.Requirements, checks)
+}
@@ -0,0 +1,194 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestSysctls creates a fake /proc/sys tree, with the values of
+// the parameters in the map.
+func writeTestSysctls(t *testing.T, values map[string]string) string {
+	root, err := ioutil.TempDir("", "greenbay-sysctl")
+	require.NoError(t, err)
+
+	for key, value := range values {
+		fn := sysctlPath(root, key)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+		require.NoError(t, ioutil.WriteFile(fn, []byte(value+"\n"), 0644))
+	}
+
+	return root
+}
+
+func TestSysctlPathTranslation(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(filepath.Join("/proc/sys", "vm", "swappiness"), sysctlPath("/proc/sys", "vm.swappiness"))
+	assert.Equal(filepath.Join("/proc/sys", "net", "ipv4", "conf", "eth0.100", "forwarding"),
+		sysctlPath("/proc/sys", "net.ipv4.conf.eth0/100.forwarding"))
+}
+
+func TestSysctlCheck(t *testing.T) {
+	root := writeTestSysctls(t, map[string]string{
+		"vm.swappiness":                  "1",
+		"net.ipv4.ip_local_port_range":   "32768\t60999",
+		"kernel.shmmax":                  "18446744073709551615",
+		"kernel.sched_rt_runtime_us":     "-1",
+		"kernel.core_pattern":            "|/usr/share/apport/apport %p %s %c",
+		"net.ipv4.conf.eth0/100.forward": "0",
+	})
+	defer os.RemoveAll(root)
+
+	factory, err := registry.GetJobFactory("sysctl")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		check  sysctlCheck
+		passes bool
+	}{
+		"ValueEqual":              {sysctlCheck{Key: "vm.swappiness", Value: "1"}, true},
+		"ValueNotEqual":           {sysctlCheck{Key: "vm.swappiness", Value: "60"}, false},
+		"MultiValueEqual":         {sysctlCheck{Key: "net.ipv4.ip_local_port_range", Value: "32768  60999"}, true},
+		"MultiValueNotEqual":      {sysctlCheck{Key: "net.ipv4.ip_local_port_range", Value: "1024 65000"}, false},
+		"Matches":                 {sysctlCheck{Key: "kernel.core_pattern", Matches: "^\\|.*apport"}, true},
+		"DoesNotMatch":            {sysctlCheck{Key: "kernel.core_pattern", Matches: "^core$"}, false},
+		"CompareLessThan":         {sysctlCheck{Key: "vm.swappiness", Compare: "lte", Number: "10"}, true},
+		"CompareGreaterThan":      {sysctlCheck{Key: "vm.swappiness", Compare: "gt", Number: "10"}, false},
+		"CompareLargeValue":       {sysctlCheck{Key: "kernel.shmmax", Compare: "gte", Number: "68719476736"}, true},
+		"CompareNegativeValue":    {sysctlCheck{Key: "kernel.sched_rt_runtime_us", Compare: "eq", Number: "-1"}, true},
+		"CompareAllFields":        {sysctlCheck{Key: "net.ipv4.ip_local_port_range", Compare: "gte", Number: "32768"}, true},
+		"CompareAllFieldsFails":   {sysctlCheck{Key: "net.ipv4.ip_local_port_range", Compare: "gt", Number: "32768"}, false},
+		"CompareField":            {sysctlCheck{Key: "net.ipv4.ip_local_port_range", Field: 2, Compare: "gte", Number: "60000"}, true},
+		"CompareFieldFails":       {sysctlCheck{Key: "net.ipv4.ip_local_port_range", Field: 1, Compare: "lte", Number: "1024"}, false},
+		"FieldOutOfRange":         {sysctlCheck{Key: "vm.swappiness", Field: 2, Value: "1"}, false},
+		"CompareNonNumeric":       {sysctlCheck{Key: "kernel.core_pattern", Compare: "eq", Number: "1"}, false},
+		"SlashInKey":              {sysctlCheck{Key: "net.ipv4.conf.eth0/100.forward", Value: "0"}, true},
+		"MissingKey":              {sysctlCheck{Key: "vm.does_not_exist", Value: "1"}, false},
+		"NoKey":                   {sysctlCheck{Value: "1"}, false},
+		"NoAssertions":            {sysctlCheck{Key: "vm.swappiness"}, false},
+		"InvalidComparison":       {sysctlCheck{Key: "vm.swappiness", Compare: "bigger", Number: "1"}, false},
+		"InvalidNumber":           {sysctlCheck{Key: "vm.swappiness", Compare: "eq", Number: "one"}, false},
+		"NumberWithoutComparison": {sysctlCheck{Key: "vm.swappiness", Number: "1"}, false},
+		"InvalidPattern":          {sysctlCheck{Key: "vm.swappiness", Matches: "(["}, false},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*sysctlCheck)
+			base := check.Base
+			*check = test.check
+			check.Base = base
+			check.sysRoot = root
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			if test.passes {
+				assert.NoError(t, check.Error())
+				assert.True(t, strings.HasPrefix(output.Message, test.check.Key+" = "), output.Message)
+			} else {
+				assert.Error(t, check.Error())
+			}
+		})
+	}
+}
+
+func TestSysctlCheckFailureMessage(t *testing.T) {
+	assert := assert.New(t)
+	root := writeTestSysctls(t, map[string]string{"net.ipv4.ip_local_port_range": "32768 60999"})
+	defer os.RemoveAll(root)
+
+	check := &sysctlCheck{
+		Key:     "net.ipv4.ip_local_port_range",
+		Field:   1,
+		Compare: "lte",
+		Number:  "1024",
+		Base:    NewBase("sysctl", 0),
+		sysRoot: root,
+	}
+
+	check.Run()
+	output := check.Output()
+	assert.False(output.Passed)
+	assert.Contains(output.Message, "field 1 of sysctl net.ipv4.ip_local_port_range is '32768', expected lte 1024")
+}
+
+func TestSysctlGroup(t *testing.T) {
+	root := writeTestSysctls(t, map[string]string{
+		"vm.swappiness":                "1",
+		"vm.zone_reclaim_mode":         "0",
+		"net.ipv4.ip_local_port_range": "32768 60999",
+	})
+	defer os.RemoveAll(root)
+
+	newGroup := func(t *testing.T, name string) *sysctlGroup {
+		factory, err := registry.GetJobFactory(name)
+		require.NoError(t, err)
+		group, ok := factory().(*sysctlGroup)
+		require.True(t, ok)
+		group.sysRoot = root
+		return group
+	}
+
+	t.Run("NoChecks", func(t *testing.T) {
+		group := newGroup(t, "sysctl-group-all")
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.False(t, output.Passed)
+		assert.Error(t, group.Error())
+	})
+
+	t.Run("AllPassing", func(t *testing.T) {
+		group := newGroup(t, "sysctl-group-all")
+		group.Checks = []*sysctlCheck{
+			{Key: "vm.swappiness", Compare: "lte", Number: "1"},
+			{Key: "vm.zone_reclaim_mode", Value: "0"},
+			{Key: "net.ipv4.ip_local_port_range", Field: 1, Compare: "gte", Number: "1024"},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.True(t, output.Passed)
+		assert.NoError(t, group.Error())
+	})
+
+	t.Run("AllWithFailure", func(t *testing.T) {
+		group := newGroup(t, "sysctl-group-all")
+		group.Checks = []*sysctlCheck{
+			{Key: "vm.swappiness", Compare: "lte", Number: "1"},
+			{Key: "vm.zone_reclaim_mode", Value: "1"},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.False(t, output.Passed)
+		assert.Error(t, group.Error())
+		assert.Contains(t, output.Message, "vm.zone_reclaim_mode is '0', expected '1'")
+	})
+
+	t.Run("NoneWithPassingCheck", func(t *testing.T) {
+		group := newGroup(t, "sysctl-group-none")
+		group.Checks = []*sysctlCheck{
+			{Key: "vm.swappiness", Value: "60"},
+			{Key: "vm.zone_reclaim_mode", Value: "0"},
+		}
+		group.Run()
+
+		output := group.Output()
+		assert.True(t, output.Completed)
+		assert.False(t, output.Passed)
+	})
+}
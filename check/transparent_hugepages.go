@@ -0,0 +1,180 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "transparent-hugepages"
+
+	registry.AddJobType(name, func() amboy.Job {
+		return &transparentHugepagesCheck{
+			Base:    NewBase(name, 0),
+			sysRoot: "/sys",
+		}
+	})
+}
+
+// thpDirectories are the locations of the transparent hugepage
+// settings, relative to /sys, in order of preference. Older Red Hat
+// kernels use the second.
+var thpDirectories = []string{
+	"kernel/mm/transparent_hugepage",
+	"kernel/mm/redhat_transparent_hugepage",
+}
+
+// transparentHugepagesCheck checks the active transparent hugepage
+// settings, which are the bracketed values in
+// /sys/kernel/mm/transparent_hugepage/{enabled,defrag}. Enabled and
+// Defrag are the expected values (e.g. "never"); kernels without
+// transparent hugepages satisfy an expectation of "never". This check
+// is only available on linux.
+type transparentHugepagesCheck struct {
+	Enabled string `bson:"enabled" json:"enabled" yaml:"enabled"`
+	Defrag  string `bson:"defrag" json:"defrag" yaml:"defrag"`
+	*Base   `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	sysRoot string
+}
+
+// thpSetting is the active value of a transparent hugepage setting,
+// and the values that it may have.
+type thpSetting struct {
+	active  string
+	options []string
+}
+
+// parseTHPSetting parses a transparent hugepage setting, such as
+// "always madvise [never]".
+func parseTHPSetting(value string) (*thpSetting, error) {
+	out := &thpSetting{}
+	for _, field := range strings.Fields(value) {
+		if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+			field = strings.Trim(field, "[]")
+			out.active = field
+		}
+		out.options = append(out.options, field)
+	}
+
+	if out.active == "" {
+		return nil, errors.Errorf("'%s' does not have an active value", strings.TrimSpace(value))
+	}
+
+	return out, nil
+}
+
+func (s *thpSetting) hasOption(value string) bool {
+	for _, option := range s.options {
+		if option == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readTHPSettings returns the transparent hugepage settings by name,
+// or nil if the kernel does not support transparent hugepages.
+func readTHPSettings(sysRoot string) (map[string]*thpSetting, error) {
+	for _, dir := range thpDirectories {
+		dir = filepath.Join(sysRoot, dir)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		out := map[string]*thpSetting{}
+		for _, name := range []string{"enabled", "defrag"} {
+			fn := filepath.Join(dir, name)
+			data, err := ioutil.ReadFile(fn)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem reading transparent hugepage setting '%s'", fn)
+			}
+
+			setting, err := parseTHPSetting(string(data))
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing transparent hugepage setting '%s'", fn)
+			}
+			out[name] = setting
+		}
+
+		return out, nil
+	}
+
+	return nil, nil
+}
+
+func (c *transparentHugepagesCheck) Run() {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if c.Enabled == "" && c.Defrag == "" {
+		c.setState(false)
+		c.AddError(errors.Errorf("no transparent hugepage settings specified for '%s' (%s) check",
+			c.ID(), c.Name()))
+		return
+	}
+
+	settings, err := readTHPSettings(c.sysRoot)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	catcher := grip.NewCatcher()
+	expectations := []struct {
+		name     string
+		expected string
+	}{
+		{"enabled", c.Enabled},
+		{"defrag", c.Defrag},
+	}
+
+	if settings == nil {
+		c.setMessage("transparent hugepages are not supported by the kernel")
+		for _, setting := range expectations {
+			if setting.expected != "" && setting.expected != "never" {
+				catcher.Add(errors.Errorf("transparent hugepage %s is not supported, expected '%s'",
+					setting.name, setting.expected))
+			}
+		}
+	} else {
+		c.setMessage(fmt.Sprintf("transparent hugepages: enabled=%s defrag=%s",
+			settings["enabled"].active, settings["defrag"].active))
+
+		for _, setting := range expectations {
+			if setting.expected == "" {
+				continue
+			}
+
+			actual := settings[setting.name]
+			if !actual.hasOption(setting.expected) {
+				catcher.Add(errors.Errorf("'%s' is not a valid transparent hugepage %s setting (options: %s)",
+					setting.expected, setting.name, strings.Join(actual.options, ", ")))
+				continue
+			}
+
+			if actual.active != setting.expected {
+				catcher.Add(errors.Errorf("transparent hugepage %s is '%s', expected '%s'",
+					setting.name, actual.active, setting.expected))
+			}
+		}
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
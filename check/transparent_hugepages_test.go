@@ -0,0 +1,86 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestTHPSettings creates a fake /sys tree with transparent
+// hugepage settings in dir.
+func writeTestTHPSettings(t *testing.T, dir, enabled, defrag string) string {
+	root, err := ioutil.TempDir("", "greenbay-thp")
+	require.NoError(t, err)
+
+	dir = filepath.Join(root, dir)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "enabled"), []byte(enabled+"\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "defrag"), []byte(defrag+"\n"), 0644))
+
+	return root
+}
+
+func TestParseTHPSetting(t *testing.T) {
+	assert := assert.New(t)
+
+	setting, err := parseTHPSetting("always defer defer+madvise [madvise] never\n")
+	assert.NoError(err)
+	assert.Equal("madvise", setting.active)
+	assert.Equal([]string{"always", "defer", "defer+madvise", "madvise", "never"}, setting.options)
+	assert.True(setting.hasOption("never"))
+	assert.False(setting.hasOption("sometimes"))
+
+	_, err = parseTHPSetting("always madvise never")
+	assert.Error(err)
+}
+
+func TestTransparentHugepagesCheck(t *testing.T) {
+	enabled := writeTestTHPSettings(t, thpDirectories[0], "[always] madvise never", "always defer [madvise] never")
+	defer os.RemoveAll(enabled)
+	disabled := writeTestTHPSettings(t, thpDirectories[1], "always madvise [never]", "always madvise [never]")
+	defer os.RemoveAll(disabled)
+	unsupported, err := ioutil.TempDir("", "greenbay-thp")
+	require.NoError(t, err)
+	defer os.RemoveAll(unsupported)
+
+	factory, err := registry.GetJobFactory("transparent-hugepages")
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		root    string
+		enabled string
+		defrag  string
+		passes  bool
+		message string
+	}{
+		"Disabled":                {disabled, "never", "never", true, "enabled=never defrag=never"},
+		"Enabled":                 {enabled, "never", "", false, "enabled=always defrag=madvise"},
+		"ExpectedEnabled":         {enabled, "always", "madvise", true, "enabled=always defrag=madvise"},
+		"DefragOnly":              {enabled, "", "never", false, "enabled=always defrag=madvise"},
+		"InvalidSetting":          {enabled, "off", "", false, "enabled=always defrag=madvise"},
+		"NoSettings":              {enabled, "", "", false, ""},
+		"UnsupportedIsDisabled":   {unsupported, "never", "never", true, "not supported"},
+		"UnsupportedIsNotEnabled": {unsupported, "always", "", false, "not supported"},
+	}
+
+	for name, test := range cases {
+		t.Run(name, func(t *testing.T) {
+			check := factory().(*transparentHugepagesCheck)
+			check.sysRoot = test.root
+			check.Enabled = test.enabled
+			check.Defrag = test.defrag
+
+			check.Run()
+			output := check.Output()
+
+			assert.True(t, output.Completed)
+			assert.Equal(t, test.passes, output.Passed, output.Error)
+			assert.Contains(t, output.Message, test.message)
+		})
+	}
+}
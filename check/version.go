@@ -1,7 +1,11 @@
 package check
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/blang/semver"
+	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
@@ -21,3 +25,195 @@ func compareVersions(rel string, actual, expected semver.Version) (bool, error)
 		return false, errors.Errorf("relationship '%s' is not valid", rel)
 	}
 }
+
+func validateRelationship(rel string) error {
+	switch rel {
+	case "gte", "lte", "lt", "gt", "eq", "":
+		return nil
+	default:
+		return errors.Errorf("relationship '%s' is not valid", rel)
+	}
+}
+
+// normalizeVersion converts partial or loosely formatted version
+// strings (e.g. "4.8", "v2", or "1.02.3") into a form that
+// semver.Parse accepts, by removing a leading "v", padding missing
+// minor and patch components with zeros, and removing leading zeros.
+func normalizeVersion(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+
+	var suffix string
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		suffix = version[idx:]
+		version = version[:idx]
+	}
+
+	parts := strings.Split(version, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+
+	for idx, part := range parts {
+		trimmed := strings.TrimLeft(part, "0")
+		if trimmed == "" && part != "" {
+			trimmed = "0"
+		}
+		parts[idx] = trimmed
+	}
+
+	return strings.Join(parts, ".") + suffix
+}
+
+// parseVersion parses a version string, after normalizing partial
+// versions, into a semver.Version.
+func parseVersion(version string) (semver.Version, error) {
+	parsed, err := semver.Parse(normalizeVersion(version))
+	if err != nil {
+		return semver.Version{}, errors.Wrapf(err, "problem parsing version '%s'", version)
+	}
+
+	return parsed, nil
+}
+
+// normalizeVersionRange parses the version in each comparator of a
+// range expression (e.g. ">=2.7 <3 || >=3.6") using the given version
+// scheme, so that versions in ranges are accepted and ordered just as
+// they are elsewhere.
+func normalizeVersionRange(expr, scheme string) string {
+	tokens := strings.Fields(expr)
+
+	for idx, token := range tokens {
+		if token == "||" {
+			continue
+		}
+
+		split := strings.IndexFunc(token, func(r rune) bool {
+			return !strings.ContainsRune("<>=!", r)
+		})
+		if split < 0 {
+			continue
+		}
+
+		parsed, _, err := parseSchemeVersion(scheme, token[split:])
+		if err != nil {
+			// leave the token alone, so that the range parser
+			// can report the error in context.
+			continue
+		}
+
+		tokens[idx] = token[:split] + parsed.String()
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+func parseVersionRange(expr, scheme string) (semver.Range, error) {
+	rng, err := semver.ParseRange(normalizeVersionRange(expr, scheme))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem parsing version range '%s'", expr)
+	}
+
+	return rng, nil
+}
+
+// versionConstraint describes the expected version for all checks
+// that compare versions. Range, if specified, is a range expression
+// (e.g. ">=2.7.0 <3.0.0 || >=3.6.0") and takes precedence over the
+// Version/Relationship and MinVersion/MinRelationship pairs, which
+// remain for compatibility with existing configurations. Scheme
+// names the version scheme used to parse all expected versions (see
+// versionSchemes.)
+type versionConstraint struct {
+	Scheme          string
+	Range           string
+	Version         string
+	Relationship    string
+	MinVersion      string
+	MinRelationship string
+}
+
+func (vc versionConstraint) parse(version string) (semver.Version, error) {
+	parsed, _, err := parseSchemeVersion(vc.Scheme, version)
+	return parsed, err
+}
+
+func (vc versionConstraint) validate() error {
+	if err := validateVersionScheme(vc.Scheme); err != nil {
+		return err
+	}
+
+	if vc.Range != "" {
+		_, err := parseVersionRange(vc.Range, vc.Scheme)
+		return err
+	}
+
+	if vc.Version == "" {
+		return errors.New("no version or version range specified")
+	}
+
+	catcher := grip.NewCatcher()
+	catcher.Add(validateRelationship(vc.Relationship))
+	catcher.Add(validateRelationship(vc.MinRelationship))
+	if _, err := vc.parse(vc.Version); err != nil {
+		catcher.Add(err)
+	}
+
+	if vc.MinVersion != "" {
+		if _, err := vc.parse(vc.MinVersion); err != nil {
+			catcher.Add(err)
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+func (vc versionConstraint) String() string {
+	if vc.Range != "" {
+		return fmt.Sprintf("'%s'", vc.Range)
+	}
+
+	out := fmt.Sprintf("%s %s", vc.Relationship, vc.Version)
+	if vc.MinVersion != "" {
+		out = fmt.Sprintf("%s and %s %s", out, vc.MinRelationship, vc.MinVersion)
+	}
+
+	return out
+}
+
+// satisfiedBy reports if the actual version satisfies the constraint.
+func (vc versionConstraint) satisfiedBy(actual semver.Version) (bool, error) {
+	if vc.Range != "" {
+		rng, err := parseVersionRange(vc.Range, vc.Scheme)
+		if err != nil {
+			return false, err
+		}
+
+		return rng(actual), nil
+	}
+
+	expected, err := vc.parse(vc.Version)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := compareVersions(vc.Relationship, actual, expected)
+	if err != nil {
+		return false, err
+	}
+
+	if vc.MinVersion != "" {
+		minExpected, err := vc.parse(vc.MinVersion)
+		if err != nil {
+			return false, err
+		}
+
+		gteMin, err := compareVersions(vc.MinRelationship, actual, minExpected)
+		if err != nil {
+			return false, err
+		}
+
+		result = result && gteMin
+	}
+
+	return result, nil
+}
@@ -0,0 +1,375 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// Version schemes describe how to parse version strings reported by
+// programs and modules. All schemes produce semver.Version values so
+// that every version-aware check can share the same comparison
+// logic, in addition to a normalized string to report to users.
+//
+//   - "semver" (or "") requires semantic versions, but will pad
+//     partial versions like "4.8" to "4.8.0".
+//
+//   - "pep440" parses Python packaging versions (e.g. "1.11",
+//     "2.0.0rc1", "0.9.post3", "1.0.dev2") and preserves PEP 440
+//     ordering of development, pre-, and post-releases.
+//
+//   - "loose" takes the leading numeric components as the version
+//     and treats anything that follows as a pre-release.
+//
+//   - "rpm" and "dpkg" parse package versions (e.g. "2.17-317.el7",
+//     "1:1.1.1f-1ubuntu2.16"), which have an optional epoch that
+//     takes precedence over the rest of the version, an upstream
+//     version, and an optional distribution release that only breaks
+//     ties between equal upstream versions.
+var versionSchemes = map[string]func(string) (semver.Version, string, error){
+	"":       parseSemverScheme,
+	"semver": parseSemverScheme,
+	"pep440": parsePEP440Version,
+	"loose":  parseLooseVersion,
+	"rpm":    parsePackageVersion,
+	"dpkg":   parsePackageVersion,
+}
+
+func validateVersionScheme(scheme string) error {
+	if _, ok := versionSchemes[scheme]; !ok {
+		return errors.Errorf("version scheme '%s' is not valid", scheme)
+	}
+
+	return nil
+}
+
+// parseSchemeVersion parses a version using the named scheme,
+// returning the parsed version and a normalized representation of
+// the version.
+func parseSchemeVersion(scheme, version string) (semver.Version, string, error) {
+	parser, ok := versionSchemes[scheme]
+	if !ok {
+		return semver.Version{}, "", errors.Errorf("version scheme '%s' is not valid", scheme)
+	}
+
+	return parser(version)
+}
+
+func parseSemverScheme(version string) (semver.Version, string, error) {
+	parsed, err := parseVersion(version)
+	if err != nil {
+		return semver.Version{}, "", err
+	}
+
+	return parsed, parsed.String(), nil
+}
+
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|b|c|rc|alpha|beta|pre|preview)[-_.]?(\d+)?)?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d+)?)?` +
+	`(?:[-_.]?(dev)[-_.]?(\d+)?)?` +
+	`(?:\+([a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+var pep440PreReleasePhases = map[string]int{
+	"a":       1,
+	"alpha":   1,
+	"b":       2,
+	"beta":    2,
+	"c":       3,
+	"rc":      3,
+	"pre":     3,
+	"preview": 3,
+}
+
+var pep440PhaseNames = map[int]string{1: "a", 2: "b", 3: "rc"}
+
+func atoiOrZero(value string) int {
+	out, _ := strconv.Atoi(value)
+	return out
+}
+
+// parsePEP440Version converts a PEP 440 version into a semver.Version
+// that orders the same way. Semantic versions have no equivalent of
+// post-releases, so the pre-release field of the returned version
+// encodes the release phase of every version (development release,
+// pre-release, final, or post-release) as a sequence of numeric
+// identifiers, which follow any release segments after the third.
+// Local version labels are stored as build metadata, which does not
+// affect ordering.
+func parsePEP440Version(version string) (semver.Version, string, error) {
+	match := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if match == nil {
+		return semver.Version{}, "", errors.Errorf("'%s' is not a valid PEP 440 version", version)
+	}
+
+	epoch, release, preLabel, preNum := match[1], match[2], match[3], match[4]
+	implicitPost, postLabel, postNum := match[5], match[6], match[7]
+	devLabel, devNum, local := match[8], match[9], match[10]
+
+	if epoch != "" && atoiOrZero(epoch) != 0 {
+		return semver.Version{}, "", errors.Errorf("version epochs are not supported ('%s')", version)
+	}
+
+	segments := strings.Split(release, ".")
+	numbers := make([]uint64, 3)
+	for idx := 0; idx < 3 && idx < len(segments); idx++ {
+		numbers[idx], _ = strconv.ParseUint(segments[idx], 10, 64)
+	}
+
+	hasPost := implicitPost != "" || postLabel != ""
+	if implicitPost != "" {
+		postNum = implicitPost
+	}
+
+	var phases []int
+	normalized := strings.Join(segments, ".")
+
+	if preLabel != "" {
+		phase := pep440PreReleasePhases[preLabel]
+		phases = append(phases, phase, atoiOrZero(preNum))
+		normalized += fmt.Sprintf("%s%d", pep440PhaseNames[phase], atoiOrZero(preNum))
+
+		if hasPost {
+			phases = append(phases, 2, atoiOrZero(postNum))
+		}
+	} else if hasPost {
+		phases = append(phases, 5, atoiOrZero(postNum))
+	} else if devLabel != "" {
+		phases = append(phases, 0)
+	} else {
+		phases = append(phases, 4)
+	}
+
+	if hasPost {
+		normalized += fmt.Sprintf(".post%d", atoiOrZero(postNum))
+	}
+
+	if devLabel != "" {
+		phases = append(phases, 0, atoiOrZero(devNum))
+		normalized += fmt.Sprintf(".dev%d", atoiOrZero(devNum))
+	} else if preLabel != "" || hasPost {
+		phases = append(phases, 1)
+	}
+
+	parsed := semver.Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}
+	parsed.Pre = appendReleaseSegments(parsed.Pre, segments)
+	for _, phase := range phases {
+		parsed.Pre = append(parsed.Pre, semver.PRVersion{VersionNum: uint64(phase), IsNum: true})
+	}
+
+	if local != "" {
+		normalized += "+" + local
+		parsed.Build = append(parsed.Build, strings.FieldsFunc(local, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})...)
+	}
+
+	return parsed, normalized, nil
+}
+
+// release segments after the third are encoded as pairs of
+// pre-release identifiers, a kind and then the value of the segment,
+// followed by an end marker, so that "1.2.3.4" sorts after "1.2.3"
+// and before "1.2.3.5". Trailing zero segments are ignored, as
+// "1.2.3.0" and "1.2.3" are the same version.
+const (
+	releaseSegmentEnd uint64 = iota
+	releaseSegmentNumeric
+)
+
+// appendReleaseSegments appends the release segments after the third
+// to the identifiers, followed by an end marker.
+func appendReleaseSegments(ids []semver.PRVersion, segments []string) []semver.PRVersion {
+	var extra []uint64
+	for idx := 3; idx < len(segments); idx++ {
+		num, _ := strconv.ParseUint(segments[idx], 10, 64)
+		extra = append(extra, num)
+	}
+
+	for len(extra) > 0 && extra[len(extra)-1] == 0 {
+		extra = extra[:len(extra)-1]
+	}
+
+	for _, num := range extra {
+		ids = append(ids,
+			semver.PRVersion{VersionNum: releaseSegmentNumeric, IsNum: true},
+			semver.PRVersion{VersionNum: num, IsNum: true})
+	}
+
+	return append(ids, semver.PRVersion{VersionNum: releaseSegmentEnd, IsNum: true})
+}
+
+var looseVersionPattern = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(.*)$`)
+
+// parseLooseVersion uses the leading numeric components of a version
+// as the major, minor, and patch versions, and treats any remaining
+// alphanumeric components as pre-release identifiers. The pre-release
+// field of the returned version encodes the release segments after
+// the third, and then whether the version has pre-release identifiers
+// (which sort before the same version without them), so the
+// normalized version is reported separately.
+func parseLooseVersion(version string) (semver.Version, string, error) {
+	match := looseVersionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return semver.Version{}, "", errors.Errorf("'%s' does not begin with a version number", version)
+	}
+
+	segments := strings.Split(match[1], ".")
+	for len(segments) < 3 {
+		segments = append(segments, "0")
+	}
+
+	release := make([]string, len(segments))
+	numbers := make([]uint64, 3)
+	for idx, segment := range segments {
+		num, _ := strconv.ParseUint(segment, 10, 64)
+		release[idx] = strconv.FormatUint(num, 10)
+		if idx < 3 {
+			numbers[idx] = num
+		}
+	}
+
+	identifiers := strings.FieldsFunc(match[2], func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+
+	var pre []semver.PRVersion
+	for _, id := range identifiers {
+		if num, err := strconv.ParseUint(id, 10, 64); err == nil {
+			pre = append(pre, semver.PRVersion{VersionNum: num, IsNum: true})
+			continue
+		}
+
+		pre = append(pre, semver.PRVersion{VersionStr: id})
+	}
+
+	parsed := semver.Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}
+	parsed.Pre = appendReleaseSegments(parsed.Pre, segments)
+	normalized := strings.Join(release, ".")
+
+	if len(pre) == 0 {
+		parsed.Pre = append(parsed.Pre, semver.PRVersion{VersionNum: 1, IsNum: true})
+		return parsed, normalized, nil
+	}
+
+	parsed.Pre = append(parsed.Pre, semver.PRVersion{VersionNum: 0, IsNum: true})
+	parsed.Pre = append(parsed.Pre, pre...)
+
+	ids := make([]string, len(pre))
+	for idx, id := range pre {
+		ids[idx] = id.String()
+	}
+
+	return parsed, normalized + "-" + strings.Join(ids, "."), nil
+}
+
+var packageVersionPattern = regexp.MustCompile(`^(?:(\d+):)?(\d+)(?:\.(\d+))?(?:\.(\d+))?(.*)$`)
+
+// package version segments are encoded as pairs of pre-release
+// identifiers: a kind, then the value of the segment. The kinds
+// order segments the way that rpm and dpkg do, where a tilde sorts
+// before everything, including the end of the version, and numbers
+// sort after letters.
+const (
+	packageSegmentTilde uint64 = iota
+	packageSegmentEnd
+	packageSegmentAlpha
+	packageSegmentNumeric
+)
+
+// appendPackageVersionSegments splits a version into runs of digits
+// and letters, ignoring other separators, and appends them to the
+// identifiers, followed by an end marker.
+func appendPackageVersionSegments(ids []semver.PRVersion, version string) ([]semver.PRVersion, error) {
+	for idx := 0; idx < len(version); {
+		char := version[idx]
+		end := idx + 1
+
+		switch {
+		case char == '~':
+			ids = append(ids, semver.PRVersion{VersionNum: packageSegmentTilde, IsNum: true})
+		case char >= '0' && char <= '9':
+			for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+				end++
+			}
+
+			num, err := strconv.ParseUint(version[idx:end], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem parsing version segment '%s'", version[idx:end])
+			}
+
+			ids = append(ids,
+				semver.PRVersion{VersionNum: packageSegmentNumeric, IsNum: true},
+				semver.PRVersion{VersionNum: num, IsNum: true})
+		case char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z':
+			for end < len(version) && (version[end] >= 'a' && version[end] <= 'z' || version[end] >= 'A' && version[end] <= 'Z') {
+				end++
+			}
+
+			ids = append(ids,
+				semver.PRVersion{VersionNum: packageSegmentAlpha, IsNum: true},
+				semver.PRVersion{VersionStr: version[idx:end]})
+		}
+
+		idx = end
+	}
+
+	return append(ids, semver.PRVersion{VersionNum: packageSegmentEnd, IsNum: true}), nil
+}
+
+// parsePackageVersion parses versions in the "epoch:upstream-release"
+// form that rpm and dpkg use. The epoch, which is 0 if omitted, is the
+// major version, so that "1:1.0" sorts after "2.0". The first two
+// numeric components of the upstream version are the minor and patch
+// versions, and the pre-release field encodes the rest of the upstream
+// version followed by the release, so that "2.17-317.el7" sorts after
+// "2.17" and "1.1.1f" sorts after "1.1.1".
+func parsePackageVersion(version string) (semver.Version, string, error) {
+	version = strings.TrimSpace(version)
+	match := packageVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return semver.Version{}, "", errors.Errorf("'%s' does not begin with a version number", version)
+	}
+
+	numbers := make([]uint64, 4)
+	for idx, segment := range match[1:5] {
+		if segment == "" {
+			continue
+		}
+
+		num, err := strconv.ParseUint(segment, 10, 64)
+		if err != nil {
+			return semver.Version{}, "", errors.Wrapf(err, "problem parsing version '%s'", version)
+		}
+		numbers[idx] = num
+	}
+
+	// the release follows the last hyphen, so that upstream
+	// versions may contain hyphens.
+	upstream, release := match[5], ""
+	if idx := strings.LastIndex(upstream, "-"); idx >= 0 {
+		upstream, release = upstream[:idx], upstream[idx+1:]
+	}
+
+	parsed := semver.Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}
+	parsed.Pre = append(parsed.Pre, semver.PRVersion{VersionNum: numbers[3], IsNum: true})
+
+	var err error
+	parsed.Pre, err = appendPackageVersionSegments(parsed.Pre, upstream)
+	if err != nil {
+		return semver.Version{}, "", errors.Wrapf(err, "problem parsing version '%s'", version)
+	}
+
+	if release != "" {
+		parsed.Pre, err = appendPackageVersionSegments(parsed.Pre, release)
+		if err != nil {
+			return semver.Version{}, "", errors.Wrapf(err, "problem parsing version '%s'", version)
+		}
+	}
+
+	return parsed, version, nil
+}
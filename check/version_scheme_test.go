@@ -0,0 +1,282 @@
+package check
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPEP440Parsing(t *testing.T) {
+	assert := assert.New(t)
+
+	normalized := map[string]string{
+		"1.11":            "1.11",
+		"2.0.0rc1":        "2.0.0rc1",
+		"2.0.0-RC1":       "2.0.0rc1",
+		"1.0alpha2":       "1.0a2",
+		"1.0-beta":        "1.0b0",
+		"0.9.post3":       "0.9.post3",
+		"0.9-3":           "0.9.post3",
+		"1.0.dev2":        "1.0.dev2",
+		"1.0a1.post2.dev": "1.0a1.post2.dev0",
+		"v1.2.3.4+ubuntu": "1.2.3.4+ubuntu",
+		"0!1.0":           "1.0",
+	}
+
+	for input, expected := range normalized {
+		_, actual, err := parsePEP440Version(input)
+		assert.NoError(err, input)
+		assert.Equal(expected, actual, input)
+	}
+
+	for _, input := range []string{"", "foo", "1.0.0-foo", "1!2.0", "1..0"} {
+		_, _, err := parsePEP440Version(input)
+		assert.Error(err, input)
+	}
+
+	// these versions are in ascending order according to PEP 440.
+	ordered := []string{
+		"1.0.dev1",
+		"1.0a1.dev1",
+		"1.0a1",
+		"1.0a1.post1.dev1",
+		"1.0a1.post1",
+		"1.0a2",
+		"1.0b1",
+		"1.0rc1",
+		"1.0",
+		"1.0.post1.dev1",
+		"1.0.post1",
+		"1.0.post2",
+		"1.0.0.1.dev1",
+		"1.0.0.1",
+		"1.0.0.2",
+		"1.0.0.10",
+		"1.1.dev1",
+		"1.1",
+		"1.11",
+		"2.0",
+		"2.0.0.1rc1",
+		"2.0.0.1",
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		lower, _, err := parsePEP440Version(ordered[idx-1])
+		assert.NoError(err)
+		higher, _, err := parsePEP440Version(ordered[idx])
+		assert.NoError(err)
+
+		assert.True(lower.LT(higher), "%s < %s", ordered[idx-1], ordered[idx])
+	}
+
+	// local versions and trailing zero release segments do not affect
+	// ordering
+	for _, input := range []string{"1.0+local.1", "1.0.0.0", "1.0.0.0.0"} {
+		a, _, err := parsePEP440Version(input)
+		assert.NoError(err, input)
+		b, _, _ := parsePEP440Version("1.0")
+		assert.True(a.EQ(b), input)
+	}
+
+	a, _, _ := parsePEP440Version("1.2.3.4")
+	b, _, _ := parsePEP440Version("1.2.3.5")
+	assert.False(a.EQ(b))
+	assert.True(a.LT(b))
+	assert.True(b.GT(a))
+}
+
+func TestLooseVersionParsing(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]string{
+		"1.11":       "1.11.0",
+		"v2":         "2.0.0",
+		"2.0.0rc1":   "2.0.0-rc1",
+		"0.9.post3":  "0.9.0-post3",
+		"1.2.3.4":    "1.2.3.4",
+		"1.02.3.04":  "1.2.3.4",
+		"3.4.1 beta": "3.4.1-beta",
+		"1.2-rc.01":  "1.2.0-rc.1",
+	}
+
+	for input, expected := range cases {
+		_, actual, err := parseLooseVersion(input)
+		assert.NoError(err, input)
+		assert.Equal(expected, actual, input)
+	}
+
+	_, _, err := parseLooseVersion("version 1.0")
+	assert.Error(err)
+
+	// these versions are in ascending order.
+	ordered := []string{
+		"1.2.2.9",
+		"1.2.3beta",
+		"1.2.3rc1",
+		"1.2.3",
+		"1.2.3.4rc1",
+		"1.2.3.4",
+		"1.2.3.5",
+		"1.2.3.10",
+		"1.2.4",
+		"2.0.0",
+		"2.0.0.1",
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		lower, _, err := parseLooseVersion(ordered[idx-1])
+		assert.NoError(err, ordered[idx-1])
+		higher, _, err := parseLooseVersion(ordered[idx])
+		assert.NoError(err, ordered[idx])
+
+		assert.True(lower.LT(higher), "%s < %s", ordered[idx-1], ordered[idx])
+	}
+
+	a, _, _ := parseLooseVersion("2.0.0.0")
+	b, _, _ := parseLooseVersion("2.0")
+	assert.True(a.EQ(b))
+
+	// ranges order extra release segments the same way
+	actual, _, err := parseLooseVersion("1.2.3.5")
+	assert.NoError(err)
+	for expr, expected := range map[string]bool{">=1.2.3.4": true, ">1.2.3.5": false, "<1.2.3.10": true} {
+		constraint := versionConstraint{Scheme: "loose", Range: expr}
+		result, err := constraint.satisfiedBy(actual)
+		assert.NoError(err, expr)
+		assert.Equal(expected, result, expr)
+	}
+}
+
+func TestPackageVersionParsing(t *testing.T) {
+	assert := assert.New(t)
+
+	ordered := []string{
+		"1.0.1",
+		"1.0.2~rc1",
+		"1.0.2",
+		"1.0.2-1",
+		"1.0.2-1.el7",
+		"1.0.2-2",
+		"1.0.2a",
+		"1.0.2k-16",
+		"1.0.2k-19",
+		"1.0.2.1",
+		"1.1.1",
+		"1.1.1f-1ubuntu2.16",
+		"1.1.1f-1ubuntu2.17",
+		"2.17",
+		"2.17-317.el7",
+		"2.17-324.el7_9",
+	}
+
+	for idx := 1; idx < len(ordered); idx++ {
+		lower, _, err := parsePackageVersion(ordered[idx-1])
+		assert.NoError(err, ordered[idx-1])
+		higher, _, err := parsePackageVersion(ordered[idx])
+		assert.NoError(err, ordered[idx])
+
+		assert.True(lower.LT(higher), "%s < %s", ordered[idx-1], ordered[idx])
+	}
+
+	// epochs take precedence over the rest of the version
+	a, normalized, err := parsePackageVersion("1:2.17-317.el7")
+	assert.NoError(err)
+	assert.Equal("1:2.17-317.el7", normalized)
+	b, _, _ := parsePackageVersion("2.17-317.el7")
+	assert.True(b.LT(a))
+	b, _, _ = parsePackageVersion("0:2.17-317.el7")
+	assert.True(b.LT(a))
+	b, _, _ = parsePackageVersion("2.17-317.el7")
+	c, _, _ := parsePackageVersion("0:2.17-317.el7")
+	assert.True(b.EQ(c))
+
+	for _, versions := range [][2]string{
+		{"2.0", "1:1.0"},
+		{"9.9.9-99", "1:0.1"},
+		{"1:1.0", "1:1.0-1"},
+		{"1:1.1", "2:1.0"},
+	} {
+		lower, _, err := parsePackageVersion(versions[0])
+		assert.NoError(err, versions[0])
+		higher, _, err := parsePackageVersion(versions[1])
+		assert.NoError(err, versions[1])
+		assert.True(lower.LT(higher), "%s < %s", versions[0], versions[1])
+	}
+
+	for _, version := range []string{"", "el7", "v1.0", "99999999999999999999.1"} {
+		_, _, err = parsePackageVersion(version)
+		assert.Error(err, version)
+	}
+
+	// distribution releases satisfy constraints on upstream versions
+	for scheme, versions := range map[string][2]string{
+		"rpm":  {"2.17-317.el7", ">=2.17"},
+		"dpkg": {"1.1.1f-1ubuntu2.16", ">=1.1.1"},
+	} {
+		actual, _, err := parseSchemeVersion(scheme, versions[0])
+		assert.NoError(err)
+
+		constraint := versionConstraint{Scheme: scheme, Range: versions[1]}
+		assert.NoError(constraint.validate())
+		result, err := constraint.satisfiedBy(actual)
+		assert.NoError(err)
+		assert.True(result, "%s %s", versions[0], versions[1])
+
+		constraint = versionConstraint{Scheme: scheme, Version: versions[1][2:], Relationship: "gte"}
+		result, err = constraint.satisfiedBy(actual)
+		assert.NoError(err)
+		assert.True(result, "%s %s", versions[0], versions[1])
+	}
+
+	// epochs apply to minimum versions in both schemes
+	for scheme, versions := range map[string][2]string{
+		"rpm":  {"1:1.0-1.el7", "2.0"},
+		"dpkg": {"1:1.0.2n-1ubuntu5", "1.1.1"},
+	} {
+		actual, _, err := parseSchemeVersion(scheme, versions[0])
+		assert.NoError(err)
+
+		constraint := versionConstraint{Scheme: scheme, Version: versions[1], Relationship: "gte"}
+		result, err := constraint.satisfiedBy(actual)
+		assert.NoError(err)
+		assert.True(result, "%s >= %s", versions[0], versions[1])
+
+		constraint = versionConstraint{Scheme: scheme, Range: ">=1:" + versions[1]}
+		assert.NoError(constraint.validate())
+		result, err = constraint.satisfiedBy(actual)
+		assert.NoError(err)
+		assert.False(result, "%s >= 1:%s", versions[0], versions[1])
+	}
+}
+
+func TestVersionSchemes(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, scheme := range []string{"", "semver", "pep440", "loose", "rpm", "dpkg"} {
+		assert.NoError(validateVersionScheme(scheme))
+	}
+	assert.Error(validateVersionScheme("debian"))
+
+	_, _, err := parseSchemeVersion("debian", "1.0")
+	assert.Error(err)
+
+	// version constraints and ranges use the scheme for expected
+	// versions.
+	actual, _, err := parseSchemeVersion("pep440", "2.0.0rc1")
+	assert.NoError(err)
+
+	constraint := versionConstraint{Scheme: "pep440", Version: "2.0", Relationship: "lt"}
+	assert.NoError(constraint.validate())
+	result, err := constraint.satisfiedBy(actual)
+	assert.NoError(err)
+	assert.True(result)
+
+	constraint = versionConstraint{Scheme: "pep440", Range: ">=2.0.0b1 <2.0"}
+	assert.NoError(constraint.validate())
+	result, err = constraint.satisfiedBy(actual)
+	assert.NoError(err)
+	assert.True(result)
+
+	constraint = versionConstraint{Scheme: "debian", Version: "2.0"}
+	assert.Error(constraint.validate())
+}
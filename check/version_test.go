@@ -61,3 +61,105 @@ func TestVersionComparison(t *testing.T) {
 	assert.NoError(err)
 	assert.True(result)
 }
+
+func TestVersionNormalization(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]string{
+		"1.2.3":       "1.2.3",
+		"4.8":         "4.8.0",
+		"2":           "2.0.0",
+		"v1.10":       "1.10.0",
+		"1.02.003":    "1.2.3",
+		"3.6-rc0":     "3.6.0-rc0",
+		"1.0+build.1": "1.0.0+build.1",
+		"0.0":         "0.0.0",
+	}
+
+	for input, expected := range cases {
+		assert.Equal(expected, normalizeVersion(input), input)
+
+		parsed, err := parseVersion(input)
+		assert.NoError(err, input)
+		assert.Equal(expected, parsed.String())
+	}
+
+	for _, input := range []string{"", "foo", "1.2.3.4", "1.x"} {
+		_, err := parseVersion(input)
+		assert.Error(err, input)
+	}
+}
+
+func TestRelationshipValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, rel := range []string{"", "gte", "lte", "gt", "lt", "eq"} {
+		assert.NoError(validateRelationship(rel))
+	}
+
+	for _, rel := range []string{"ne", "neq", ">=", "true"} {
+		assert.Error(validateRelationship(rel))
+	}
+}
+
+func TestVersionRanges(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(">=2.7.0 <3.0.0 || >=3.6.0", normalizeVersionRange(">=2.7 <3   ||   >=3.6", ""))
+	assert.Equal("!=1.0.0", normalizeVersionRange("!=1", ""))
+
+	rng, err := parseVersionRange(">=2.7.0 <3.0.0 || >=3.6.0", "")
+	assert.NoError(err)
+	for version, expected := range map[string]bool{
+		"2.6.9":  false,
+		"2.7.0":  true,
+		"2.7.13": true,
+		"3.0.0":  false,
+		"3.5.2":  false,
+		"3.6.0":  true,
+		"4.0.0":  true,
+	} {
+		assert.Equal(expected, rng(semver.MustParse(version)), version)
+	}
+
+	for _, expr := range []string{"", ">=foo", "|| >=1.0"} {
+		_, err = parseVersionRange(expr, "")
+		assert.Error(err, expr)
+	}
+}
+
+func TestVersionConstraint(t *testing.T) {
+	assert := assert.New(t)
+	actual := semver.MustParse("2.7.13")
+
+	cases := []struct {
+		constraint versionConstraint
+		valid      bool
+		satisfied  bool
+	}{
+		{versionConstraint{}, false, false},
+		{versionConstraint{Range: ">=2.7 <3 || >=3.6"}, true, true},
+		{versionConstraint{Range: ">=3.6"}, true, false},
+		{versionConstraint{Range: "~~"}, false, false},
+		{versionConstraint{Version: "2.7", Relationship: "gte"}, true, true},
+		{versionConstraint{Version: "2.7", Relationship: "neq"}, false, false},
+		{versionConstraint{Version: "3.0", Relationship: "lt", MinVersion: "2.7", MinRelationship: "gte"}, true, true},
+		{versionConstraint{Version: "3.0", Relationship: "lt", MinVersion: "2.8", MinRelationship: "gte"}, true, false},
+		{versionConstraint{Version: "3.0", Relationship: "lt", MinVersion: "x", MinRelationship: "gte"}, false, false},
+		// range expressions take precedence over version fields
+		{versionConstraint{Range: ">=2.0", Version: "3.0", Relationship: "gte"}, true, true},
+	}
+
+	for idx, c := range cases {
+		err := c.constraint.validate()
+		if !c.valid {
+			assert.Error(err, "%d", idx)
+			continue
+		}
+		assert.NoError(err, "%d", idx)
+
+		result, err := c.constraint.satisfiedBy(actual)
+		assert.NoError(err, "%d", idx)
+		assert.Equal(c.satisfied, result, "%d: %s", idx, c.constraint)
+	}
+}
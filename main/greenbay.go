@@ -50,6 +50,7 @@ func buildApp() *cli.App {
 		checks(),
 		service(),
 		client(),
+		limitHelper(),
 	}
 
 	// need to call a function in the check package so that the
@@ -314,3 +315,15 @@ func client() cli.Command {
 	}
 
 }
+
+func limitHelper() cli.Command {
+	return cli.Command{
+		Name:            check.LimitHelperCommand,
+		Usage:           "set resource limits and execute a command (used internally by checks)",
+		Hidden:          true,
+		SkipFlagParsing: true,
+		Action: func(c *cli.Context) error {
+			return check.RunLimitHelper(c.Args())
+		},
+	}
+}
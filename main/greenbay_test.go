@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/mongodb/greenbay/check"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/level"
 	"github.com/stretchr/testify/suite"
@@ -61,3 +62,12 @@ func (s *MainSuite) TestAppBuilderFunctionSetsCorrectProperties() {
 	// we do logging set up here, so it needs to be set
 	s.NotZero(app.Before)
 }
+
+func (s *MainSuite) TestLimitHelperCommandIsHidden() {
+	app := buildApp()
+
+	command := app.Command(check.LimitHelperCommand)
+	s.Require().NotNil(command)
+	s.True(command.Hidden)
+	s.True(command.SkipFlagParsing)
+}
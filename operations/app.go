@@ -20,6 +20,7 @@ import (
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/queue"
+	"github.com/mongodb/greenbay/check"
 	"github.com/mongodb/greenbay/config"
 	"github.com/mongodb/greenbay/output"
 	"github.com/mongodb/grip"
@@ -88,12 +89,17 @@ func (a *GreenbayApp) Run(ctx context.Context) error {
 	start := time.Now()
 	catcher := grip.NewCatcher()
 
-	for check := range a.Conf.GetAllTests(a.Tests, a.Suites) {
-		if check.Err != nil {
-			catcher.Add(check.Err)
+	// package checks in this run share inventories of the
+	// installed packages, which reflect the system as of this run.
+	inventories := check.NewPackageInventories()
+
+	for unit := range a.Conf.GetAllTests(a.Tests, a.Suites) {
+		if unit.Err != nil {
+			catcher.Add(unit.Err)
 			continue
 		}
-		catcher.Add(q.Put(check.Job))
+		check.UsePackageInventories(unit.Job, inventories)
+		catcher.Add(q.Put(unit.Job))
 	}
 	if catcher.HasErrors() {
 		return errors.Wrap(catcher.Resolve(), "problem collecting and submitting jobs")
@@ -11,6 +11,7 @@ import (
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/queue"
 	"github.com/mongodb/amboy/rest"
+	"github.com/mongodb/greenbay/check"
 	"github.com/mongodb/greenbay/config"
 	"github.com/mongodb/greenbay/output"
 	"github.com/mongodb/grip"
@@ -141,12 +142,17 @@ func (s *GreenbayService) runAdhocTests(jobs <-chan config.JobWithError) (interf
 	q := queue.NewLocalUnordered(2)
 	defer q.Runner().Close()
 
+	// each request has its own package inventories, so that
+	// concurrent requests do not share or reset each other's.
+	inventories := check.NewPackageInventories()
+
 	for unit := range jobs {
 		if unit.Err != nil {
 			catcher.Add(unit.Err)
 			continue
 		}
 
+		check.UsePackageInventories(unit.Job, inventories)
 		catcher.Add(q.Put(unit.Job))
 	}
 